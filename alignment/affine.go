@@ -0,0 +1,279 @@
+package alignment
+
+import (
+	"math"
+	"sort"
+)
+
+// 仿射gap罚分，长度为n的gap罚分为Open+(n-1)*Extend；位于序列两端的gap使用EndOpen和EndExtend
+type Gap struct {
+	Open, Extend       float64
+	EndOpen, EndExtend float64
+}
+
+// 返回两端与中间罚分相同的仿射gap罚分
+func NewGap(open, extend float64) Gap {
+	return Gap{open, extend, open, extend}
+}
+
+// Gotoh算法生成的矩阵里每一个方格的数据信息，三个状态依次为两个序列都移动1字符、序列二添加空位、序列一添加空位
+type AffineCell struct {
+	sum  [3]float64
+	from [3]int8
+	ori  [3][2]int
+}
+
+// 使用仿射gap罚分的全局序列比对生成的矩阵，大小为(H+1)×(W+1)
+type AffineNW [][]AffineCell
+
+// 使用仿射gap罚分的局部序列比对生成的矩阵，大小为(H+1)×(W+1)
+type AffineSW [][]AffineCell
+
+// 三个状态中的最大值及其下标
+func best3(s [3]float64) (float64, int8) {
+	k := int8(0)
+	for i := int8(1); i < 3; i++ {
+		if s[i] > s[k] {
+			k = i
+		}
+	}
+	return s[k], k
+}
+
+// 在某一状态的基础上开启或延伸gap，返回分值和来源状态；x为延续gap时所在的状态
+func extend(c *AffineCell, x int8, open, ext float64) (float64, int8) {
+	s, k := math.Inf(-1), int8(0)
+	for i := int8(0); i < 3; i++ {
+		v := c.sum[i] - open
+		if i == x {
+			v = c.sum[i] - ext
+		}
+		if v > s {
+			s, k = v, i
+		}
+	}
+	return s, k
+}
+
+// 仿射gap罚分比对的计算环境，坐标均为(H+1)×(W+1)矩阵中的位置
+type affine struct {
+	H, W  int
+	mate  func(int, int) float64
+	gap   [2]Gap
+	coef  func(int, int) float64
+	local bool     // 是否为局部比对，局部比对可以在任意位置重新开始且不区分两端的gap
+	inner bool     // 是否不区分两端的gap
+	free  Mode     // 哪些位置的end gap不罚分
+	ban   [][]bool // 不允许对齐的字符对，为nil时不限
+}
+
+// 创建计算环境
+func newAffine(H, W int, mate func(int, int) float64, gap [2]Gap, coef func(int, int) float64, local bool) *affine {
+	if coef == nil {
+		coef = DefaultCoef
+	}
+	return &affine{H: H, W: W, mate: mate, gap: gap, coef: coef, local: local, inner: local}
+}
+
+// 移动到(i,j)时两个序列都移动1字符的得分
+func (this *affine) score(i, j int) float64 {
+	return this.coef(i-1, j-1) * this.mate(i-1, j-1)
+}
+
+// 以状态x（1为序列二添加空位，2为序列一添加空位）移动到(i,j)时开启和延伸gap的罚分，gap处使用相邻位置的修正系数
+func (this *affine) penalty(x int8, i, j int) (float64, float64) {
+	var (
+		g    *Gap
+		side Mode
+		k    float64
+	)
+	if x == 1 {
+		g = &this.gap[1]
+		switch j {
+		case 0:
+			side = FreeStart2
+		case this.W:
+			side = FreeEnd2
+		}
+		if j > 0 {
+			j--
+		}
+		k = this.coef(i-1, j)
+	} else {
+		g = &this.gap[0]
+		switch i {
+		case 0:
+			side = FreeStart1
+		case this.H:
+			side = FreeEnd1
+		}
+		if i > 0 {
+			i--
+		}
+		k = this.coef(i, j-1)
+	}
+	if side != 0 && !this.inner {
+		if this.free&side != 0 {
+			return 0, 0
+		}
+		return k * g.EndOpen, k * g.EndExtend
+	}
+	return k * g.Open, k * g.Extend
+}
+
+// 填充从(i0,j0)到(i0+h,j0+w)的子矩阵，s0为路径到达(i0,j0)时的状态，返回的矩阵使用相对坐标
+func (this *affine) fill(i0, j0, h, w int, s0 int8) [][]AffineCell {
+	inf := math.Inf(-1)
+	matrix := make([][]AffineCell, h+1)
+	for i := h; i >= 0; i-- {
+		matrix[i] = make([]AffineCell, w+1)
+	}
+	for i := 0; i <= h; i++ {
+		for j := 0; j <= w; j++ {
+			if i == 0 && j == 0 {
+				c := &matrix[0][0]
+				c.sum = [3]float64{inf, inf, inf}
+				c.sum[s0] = 0
+				continue
+			}
+			this.update(matrix, i, j, i0+i, j0+j)
+		}
+	}
+	return matrix
+}
+
+// 根据相邻的方格计算相对坐标为(i,j)、绝对坐标为(x,y)的方格
+func (this *affine) update(matrix [][]AffineCell, i, j, x, y int) {
+	inf := math.Inf(-1)
+	c := &matrix[i][j]
+	c.sum = [3]float64{inf, inf, inf}
+	if i > 0 && j > 0 && (this.ban == nil || !this.ban[x-1][y-1]) {
+		s := this.score(x, y)
+		p := &matrix[i-1][j-1]
+		v, f := best3(p.sum)
+		if this.local && v <= 0 {
+			c.sum[0], c.from[0], c.ori[0] = s, -1, [2]int{x - 1, y - 1}
+		} else {
+			c.sum[0], c.from[0], c.ori[0] = v+s, f, p.ori[f]
+		}
+	}
+	if i > 0 {
+		o, e := this.penalty(1, x, y)
+		p := &matrix[i-1][j]
+		v, f := extend(p, 1, o, e)
+		c.sum[1], c.from[1], c.ori[1] = v, f, p.ori[f]
+	}
+	if j > 0 {
+		o, e := this.penalty(2, x, y)
+		p := &matrix[i][j-1]
+		v, f := extend(p, 2, o, e)
+		c.sum[2], c.from[2], c.ori[2] = v, f, p.ori[f]
+	}
+}
+
+// 使用仿射gap罚分（Gotoh算法）的全局序列比对，mate返回两个序列指定位点的匹配值，gap[0]、gap[1]分别为在序列一、序列二中添加空位的罚分，coef为序列位置的修正系数
+func NeedlemanWunschAffine(H, W int, mate func(int, int) float64, gap [2]Gap, coef func(int, int) float64) AffineNW {
+	if mate == nil {
+		return nil
+	}
+	return newAffine(H, W, mate, gap, coef, false).fill(0, 0, H, W, 0)
+}
+
+// 返回全局比对的分值
+func (this AffineNW) First() float64 {
+	H, W := len(this)-1, len(this[0])-1
+	v, _ := best3(this[H][W].sum)
+	return v
+}
+
+// 从指定位置和状态回溯，直到回到origin或矩阵的左上角，返回对齐信息序列
+func traceback(m [][]AffineCell, x, y int, k int8) []byte {
+	s := make([]byte, 0, x+y)
+	for (x > 0 || y > 0) && k >= 0 {
+		f := m[x][y].from[k]
+		switch k {
+		case 0:
+			s = append(s, 3)
+			x, y = x-1, y-1
+		case 1:
+			s = append(s, 1)
+			x--
+		default:
+			s = append(s, 2)
+			y--
+		}
+		k = f
+	}
+	for i, j := 0, len(s)-1; i < j; i, j = i+1, j-1 {
+		s[i], s[j] = s[j], s[i]
+	}
+	return s
+}
+
+// 返回全局比对的起始点和对齐信息序列，1表示序列一移动1字符而序列二添加空位，2表示相反情况，3表示两个序列都移动1字符
+func (this AffineNW) Settle() ([2]int, []byte) {
+	H, W := len(this)-1, len(this[0])-1
+	_, k := best3(this[H][W].sum)
+	return [2]int{0, 0}, traceback(this, H, W, k)
+}
+
+// 使用仿射gap罚分（Gotoh算法）的局部序列比对，参数与NeedlemanWunschAffine相同，局部比对不区分两端的gap
+func SmithWatermanAffine(H, W int, mate func(int, int) float64, gap [2]Gap, coef func(int, int) float64) AffineSW {
+	if mate == nil {
+		return nil
+	}
+	return newAffine(H, W, mate, gap, coef, true).fill(0, 0, H, W, 0)
+}
+
+// 返回最大匹配分值所在的位置
+func (this AffineSW) top() (int, int, float64) {
+	x, y, max := 0, 0, float64(0)
+	for i := 1; i < len(this); i++ {
+		for j := 1; j < len(this[i]); j++ {
+			if score := this[i][j].sum[0]; max < score {
+				max, x, y = score, i, j
+			}
+		}
+	}
+	return x, y, max
+}
+
+// 返回最大匹配分值
+func (this AffineSW) First() float64 {
+	_, _, max := this.top()
+	return max
+}
+
+// 返回前n高的匹配分值，这些分值的匹配都有不同的起点
+func (this AffineSW) Limit(n int) []float64 {
+	S := map[[2]int]float64{}
+	for i := 1; i < len(this); i++ {
+		for j := 1; j < len(this[i]); j++ {
+			p := &this[i][j]
+			if p.sum[0] <= 0 {
+				continue
+			}
+			if t, ok := S[p.ori[0]]; !ok || t < p.sum[0] {
+				S[p.ori[0]] = p.sum[0]
+			}
+		}
+	}
+	L := []float64{}
+	for _, v := range S {
+		L = append(L, v)
+	}
+	sort.Sort(sort.Reverse(sort.Float64Slice(L)))
+	if len(L) > n {
+		L = L[:n]
+	}
+	return L
+}
+
+// 返回最高匹配分值下，匹配的起始点和对齐信息序列，编码与AffineNW.Settle相同
+func (this AffineSW) Settle() ([2]int, []byte) {
+	x, y, max := this.top()
+	if max <= 0 {
+		return [2]int{0, 0}, nil
+	}
+	return this[x][y].ori[0], traceback(this, x, y, 0)
+}
@@ -0,0 +1,58 @@
+package alignment
+
+import "testing"
+
+// 相同为1、不同为-1的计分
+func unit(a, b byte) float64 {
+	if a == b {
+		return 1
+	}
+	return -1
+}
+
+func TestNeedlemanWunschAffine(t *testing.T) {
+	gap := [2]Gap{NewGap(2, 1), NewGap(2, 1)}
+	cases := []struct {
+		p, q  string
+		score float64
+		path  []byte
+	}{
+		// ACGT / AC-T：3个匹配减去一个长度为1的gap
+		{"ACGT", "ACT", 1, []byte{3, 3, 1, 3}},
+		// 长度为3的gap罚分为2+1+1，仿射罚分使三个gap连在一起
+		{"AAAGGGTTT", "AAATTT", 2, []byte{3, 3, 3, 1, 1, 1, 3, 3, 3}},
+		// 两端的gap同样罚分
+		{"ACG", "TTACGTT", -3, []byte{2, 2, 3, 3, 3, 2, 2}},
+	}
+	for _, c := range cases {
+		m := NeedlemanWunschAffine(len(c.p), len(c.q), MatchString(c.p, c.q, unit), gap, nil)
+		o, s := m.Settle()
+		if v := m.First(); v != c.score || o != [2]int{0, 0} || string(s) != string(c.path) {
+			t.Errorf("%s / %s: got %v %v %v, want %v [0 0] %v", c.p, c.q, v, o, s, c.score, c.path)
+		}
+	}
+}
+
+func TestSmithWatermanAffine(t *testing.T) {
+	gap := [2]Gap{NewGap(2, 1), NewGap(2, 1)}
+	cases := []struct {
+		p, q   string
+		score  float64
+		origin [2]int
+		path   []byte
+	}{
+		// 只比对中间的ACG
+		{"TTACGTT", "GGACGGG", 3, [2]int{2, 2}, []byte{3, 3, 3}},
+		// ACGTACGT / ACGT-CGT：7个匹配减去一个gap
+		{"CCACGTACGTCC", "GGACGTCGTGG", 5, [2]int{2, 2}, []byte{3, 3, 3, 3, 1, 3, 3, 3}},
+		// 没有匹配时分值为0
+		{"AAAA", "TTTT", 0, [2]int{0, 0}, nil},
+	}
+	for _, c := range cases {
+		m := SmithWatermanAffine(len(c.p), len(c.q), MatchString(c.p, c.q, unit), gap, nil)
+		o, s := m.Settle()
+		if v := m.First(); v != c.score || (v > 0 && (o != c.origin || string(s) != string(c.path))) {
+			t.Errorf("%s / %s: got %v %v %v, want %v %v %v", c.p, c.q, v, o, s, c.score, c.origin, c.path)
+		}
+	}
+}
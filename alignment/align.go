@@ -11,6 +11,9 @@ type Cell struct {
 }
 
 // 全局序列比对Needleman-Wunsch动态规划算法生成的矩阵
+//
+// 注意：First、Limit和Settle都在最后一行和最后一列中选取最高分，两端的gap实际上不罚分，相当于Overlap模式的半全局比对；
+// 需要真正的全局比对时请使用Align并指定Global模式
 type MatrixNW [][]Cell
 
 // 局部序列比对SmithWaterman动态规划算法生成的矩阵
@@ -107,6 +110,7 @@ func (this MatrixNW) Limit(n int) []float64 {
 }
 
 // 返回最高匹配分值下，匹配的起始点和对齐信息序列，1表示序列一移动1字符而序列二添加空位，2表示相反情况，3表示两个序列都移动1字符
+// 最高分所在位置之后的部分以gap补齐，因此起始点总是[0, 0]
 func (this MatrixNW) Settle() ([2]int, []byte) {
 	H, W := len(this), len(this[0])
 	x, y, max := 0, 0, float64(0)
@@ -310,7 +314,7 @@ func MatchBytes(p, q []byte, f func(byte, byte) float64) func(int, int) float64
 	}
 }
 
-//
+// 根据起始点和对齐信息序列生成两个带gap的序列，string版本
 func AlignString(o [2]int, s []byte, p, q string) (string, string) {
 	i, j := o[0], o[1]
 	m := make([]byte, len(s))
@@ -331,7 +335,7 @@ func AlignString(o [2]int, s []byte, p, q string) (string, string) {
 	return string(m), string(n)
 }
 
-//
+// 根据起始点和对齐信息序列生成两个带gap的序列，[]byte版本
 func AlignBytes(o [2]int, s []byte, p, q []byte) ([]byte, []byte) {
 	i, j := o[0], o[1]
 	m := make([]byte, len(s))
@@ -0,0 +1,202 @@
+package alignment
+
+import "math"
+
+// 只保存部分列的矩阵行，lo为第一个元素对应的列
+type ragged struct {
+	lo    int
+	cells []AffineCell
+}
+
+// 只保存部分方格的矩阵
+type raggedMatrix []ragged
+
+// 返回(i,j)处的方格，不存在时返回nil
+func (this raggedMatrix) get(i, j int) *AffineCell {
+	if i < 0 || i >= len(this) {
+		return nil
+	}
+	r := &this[i]
+	if j < r.lo || j >= r.lo+len(r.cells) {
+		return nil
+	}
+	return &r.cells[j-r.lo]
+}
+
+// 计算(i,j)处的方格，i0、j0为矩阵相对于比对环境的偏移
+func (this *affine) cell(m raggedMatrix, c *AffineCell, i0, j0, i, j int) {
+	inf := math.Inf(-1)
+	c.sum = [3]float64{inf, inf, inf}
+	x, y := i0+i, j0+j
+	if p := m.get(i-1, j-1); p != nil {
+		v, f := best3(p.sum)
+		c.sum[0], c.from[0] = v+this.score(x, y), f
+	}
+	if p := m.get(i-1, j); p != nil {
+		o, e := this.penalty(1, x, y)
+		c.sum[1], c.from[1] = extend(p, 1, o, e)
+	}
+	if p := m.get(i, j-1); p != nil {
+		o, e := this.penalty(2, x, y)
+		c.sum[2], c.from[2] = extend(p, 2, o, e)
+	}
+}
+
+// 从(x,y)处的状态k回溯到(0,0)，返回对齐信息序列
+func (this raggedMatrix) traceback(x, y int, k int8) []byte {
+	s := make([]byte, 0, x+y)
+	for x > 0 || y > 0 {
+		f := this.get(x, y).from[k]
+		switch k {
+		case 0:
+			s = append(s, 3)
+			x, y = x-1, y-1
+		case 1:
+			s = append(s, 1)
+			x--
+		default:
+			s = append(s, 2)
+			y--
+		}
+		k = f
+	}
+	for i, j := 0, len(s)-1; i < j; i, j = i+1, j-1 {
+		s[i], s[j] = s[j], s[i]
+	}
+	return s
+}
+
+// 带状的全局序列比对，只计算对角线j-i在[lo, hi]范围内的方格，其余参数与NeedlemanWunschAffine相同；
+// 将gap的EndOpen和EndExtend设为0即为半全局比对；终点不在带内时返回负无穷的分值和nil
+func BandedNeedlemanWunsch(H, W int, mate func(int, int) float64, gap [2]Gap, coef func(int, int) float64, lo, hi int) (float64, [2]int, []byte) {
+	if mate == nil || lo > 0 || hi < 0 || W-H < lo || W-H > hi {
+		return math.Inf(-1), [2]int{0, 0}, nil
+	}
+	a := newAffine(H, W, mate, gap, coef, false)
+	m := make(raggedMatrix, H+1)
+	for i := 0; i <= H; i++ {
+		l, h := i+lo, i+hi
+		if l < 0 {
+			l = 0
+		}
+		if h > W {
+			h = W
+		}
+		m[i] = ragged{l, make([]AffineCell, h-l+1)}
+		for j := l; j <= h; j++ {
+			c := m.get(i, j)
+			if i == 0 && j == 0 {
+				inf := math.Inf(-1)
+				c.sum = [3]float64{0, inf, inf}
+				continue
+			}
+			a.cell(m, c, 0, 0, i, j)
+		}
+	}
+	v, k := best3(m.get(H, W).sum)
+	return v, [2]int{0, 0}, m.traceback(H, W, k)
+}
+
+// 从(i0,j0)开始向右下方进行gapped X-drop延伸，分值低于当前最高分x以上的方格被舍弃，返回最高分、终点的相对位置和对齐信息序列
+func (this *affine) xdrop(i0, j0, H, W int, x float64) (float64, [2]int, []byte) {
+	inf := math.Inf(-1)
+	m := raggedMatrix{}
+	best, bi, bj := float64(0), 0, 0
+	lo, hi := 0, 0
+	dead := [3]float64{inf, inf, inf}
+	for i := 0; i <= H; i++ {
+		m = append(m, ragged{lo, nil})
+		r := &m[i]
+		for j := lo; j <= W; j++ {
+			if j > hi+1 && (len(r.cells) == 0 || r.cells[len(r.cells)-1].sum == dead) {
+				break
+			}
+			var c AffineCell
+			if i == 0 && j == 0 {
+				c.sum = [3]float64{0, inf, inf}
+			} else {
+				this.cell(m, &c, i0, j0, i, j)
+			}
+			for k := 0; k < 3; k++ {
+				if c.sum[k] < best-x {
+					c.sum[k] = inf
+				}
+			}
+			if len(r.cells) == 0 && c.sum == dead {
+				r.lo++
+				continue
+			}
+			r.cells = append(r.cells, c)
+			if c.sum[0] > best {
+				best, bi, bj = c.sum[0], i, j
+			}
+		}
+		for n := len(r.cells); n > 0 && r.cells[n-1].sum == dead; n-- {
+			r.cells = r.cells[:n-1]
+		}
+		if len(r.cells) == 0 {
+			m = m[:i]
+			break
+		}
+		lo, hi = r.lo, r.lo+len(r.cells)-1
+	}
+	return best, [2]int{bi, bj}, m.traceback(bi, bj, 0)
+}
+
+// 从种子位置seed（两个序列上匹配的一对字符）向两侧进行gapped X-drop延伸，参数与SmithWatermanAffine相同，
+// 延伸过程中分值比已达到的最高分低x以上时停止；返回比对分值以及与Settle相同格式的起始点和对齐信息序列
+func XDrop(H, W int, mate func(int, int) float64, gap [2]Gap, coef func(int, int) float64, seed [2]int, x float64) (float64, [2]int, []byte) {
+	if mate == nil || seed[0] < 0 || seed[0] >= H || seed[1] < 0 || seed[1] >= W {
+		return 0, seed, nil
+	}
+	if coef == nil {
+		coef = DefaultCoef
+	}
+	si, sj := seed[0], seed[1]
+	a := &affine{H: H, W: W, mate: mate, gap: gap, coef: coef, inner: true}
+	rv, _, rs := a.xdrop(si+1, sj+1, H-si-1, W-sj-1, x)
+	// 向左延伸时将两个序列都反过来
+	b := &affine{H: si, W: sj, gap: gap, inner: true}
+	b.mate = func(i, j int) float64 {
+		return mate(si-1-i, sj-1-j)
+	}
+	b.coef = func(i, j int) float64 {
+		return coef(si-1-i, sj-1-j)
+	}
+	lv, le, ls := b.xdrop(0, 0, si, sj, x)
+	s := make([]byte, 0, len(ls)+len(rs)+1)
+	for i := len(ls) - 1; i >= 0; i-- {
+		s = append(s, ls[i])
+	}
+	s = append(s, 3)
+	s = append(s, rs...)
+	return lv + coef(si, sj)*mate(si, sj) + rv, [2]int{si - le[0], sj - le[1]}, s
+}
+
+// 从种子位置seed向两侧进行无gap的X-drop延伸，返回分值、起始点和比对长度
+func UngappedXDrop(H, W int, mate func(int, int) float64, seed [2]int, x float64) (float64, [2]int, int) {
+	if mate == nil || seed[0] < 0 || seed[0] >= H || seed[1] < 0 || seed[1] >= W {
+		return 0, seed, 0
+	}
+	si, sj := seed[0], seed[1]
+	sum := mate(si, sj)
+	r, best, end := float64(0), float64(0), 0
+	for k := 1; si+k < H && sj+k < W; k++ {
+		if r += mate(si+k, sj+k); r > best {
+			best, end = r, k
+		} else if r < best-x {
+			break
+		}
+	}
+	sum += best
+	l, best, start := float64(0), float64(0), 0
+	for k := 1; si-k >= 0 && sj-k >= 0; k++ {
+		if l += mate(si-k, sj-k); l > best {
+			best, start = l, k
+		} else if l < best-x {
+			break
+		}
+	}
+	sum += best
+	return sum, [2]int{si - start, sj - start}, start + end + 1
+}
@@ -0,0 +1,58 @@
+package alignment
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestBandedNeedlemanWunsch(t *testing.T) {
+	r := rand.New(rand.NewSource(11))
+	g := NewGap(3, 1)
+	gap := [2]Gap{g, g}
+	for it := 0; it < 20; it++ {
+		p := randomBytes(r, 30+r.Intn(40), "ACGT")
+		q := randomBytes(r, 30+r.Intn(40), "ACGT")
+		mate := MatchBytes(p, q, unit)
+		want := NeedlemanWunschAffine(len(p), len(q), mate, gap, nil).First()
+		// 带宽覆盖整个矩阵时与完整的动态规划相同
+		v, o, s := BandedNeedlemanWunsch(len(p), len(q), mate, gap, nil, -len(p), len(q))
+		if v != want || rescore(o, s, mate, g) != want {
+			t.Fatalf("pair %d: banded %v (path %v), want %v", it, v, rescore(o, s, mate, g), want)
+		}
+	}
+	// 带宽为0时不允许gap
+	v, _, s := BandedNeedlemanWunsch(4, 4, MatchString("ACGT", "AGGT", unit), gap, nil, 0, 0)
+	if v != 2 || string(s) != string([]byte{3, 3, 3, 3}) {
+		t.Errorf("band 0: got %v %v, want 2 [3 3 3 3]", v, s)
+	}
+	// 终点不在带内
+	if v, _, s := BandedNeedlemanWunsch(4, 6, MatchString("ACGT", "ACGTAA", unit), gap, nil, -1, 1); !math.IsInf(v, -1) || s != nil {
+		t.Errorf("end outside band: got %v %v", v, s)
+	}
+}
+
+func TestXDrop(t *testing.T) {
+	gap := [2]Gap{NewGap(2, 1), NewGap(2, 1)}
+	// 种子两侧各有2个和5个匹配，再往外都是错配
+	p, q := "GGGGACGTACGTCCCC", "AAAAACGTACGTAAAA"
+	mate := MatchString(p, q, unit)
+	v, o, s := XDrop(len(p), len(q), mate, gap, nil, [2]int{6, 6}, 3)
+	if v != 8 || o != [2]int{4, 4} || string(s) != string([]byte{3, 3, 3, 3, 3, 3, 3, 3}) {
+		t.Errorf("XDrop: got %v %v %v, want 8 [4 4] 8×3", v, o, s)
+	}
+	if v, o, l := UngappedXDrop(len(p), len(q), mate, [2]int{6, 6}, 3); v != 8 || o != [2]int{4, 4} || l != 8 {
+		t.Errorf("UngappedXDrop: got %v %v %v, want 8 [4 4] 8", v, o, l)
+	}
+	// 越过序列一中长度为2的插入：15个匹配减去gap罚分3
+	p, q = "ACGTACGTTTGCATGCA", "ACGTACGTGCATGCA"
+	mate = MatchString(p, q, unit)
+	v, o, s = XDrop(len(p), len(q), mate, gap, nil, [2]int{0, 0}, 5)
+	if v != 12 || o != [2]int{0, 0} || rescore(o, s, mate, gap[0]) != 12 {
+		t.Errorf("XDrop with gap: got %v %v %v, want 12 [0 0]", v, o, s)
+	}
+	// 无gap延伸在插入处停止
+	if v, _, l := UngappedXDrop(len(p), len(q), mate, [2]int{0, 0}, 3); v != 8 || l != 8 {
+		t.Errorf("UngappedXDrop with gap: got %v %v, want 8 8", v, l)
+	}
+}
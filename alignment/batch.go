@@ -0,0 +1,110 @@
+package alignment
+
+import (
+	"context"
+	"runtime"
+	"sync"
+
+	"github.com/hydra13142/bio/sequence"
+)
+
+// 批量两两比对的参数
+type Batch struct {
+	Matrix   *Matrix               // 替换矩阵，为nil时多肽使用BLOSUM62，其余使用NUC44
+	Gap      [2]Gap                // 两个序列中添加空位的罚分
+	Mode     Mode                  // 比对模式
+	Full     bool                  // 是否生成完整的比对结果（用LinearAlign回溯），为false时只计算分值；两种情况都只使用线性大小的内存
+	Workers  int                   // 并发比对的数量，不大于0时使用CPU的个数
+	Progress func(done, total int) // 每完成一对比对调用一次，总是在调用Run的goroutine中调用
+}
+
+// 批量比对中一对序列的比对结果，I、J为两个序列的下标
+type Result struct {
+	I, J      int
+	Score     float64
+	Alignment *Alignment // Full为false时为nil
+}
+
+// 根据序列类型选择替换矩阵
+func (this *Batch) matrix(p, q *sequence.Seq) *Matrix {
+	switch {
+	case this.Matrix != nil:
+		return this.Matrix
+	case p.Kind() == "Peptide" || q.Kind() == "Peptide":
+		return BLOSUM62
+	default:
+		return NUC44
+	}
+}
+
+// 比对一对序列
+func (this *Batch) align(p, q *sequence.Seq) (float64, *Alignment) {
+	m := this.matrix(p, q)
+	if this.Full {
+		v, o, s := LinearAlign(len(p.Char), len(q.Char), m.Mate(p, q), this.Gap, nil, this.Mode)
+		return v, NewAlignment(v, o, s, p.Char, q.Char, m.Get)
+	}
+	return AlignScore(len(p.Char), len(q.Char), m.Mate(p, q), this.Gap, nil, this.Mode), nil
+}
+
+// 并发地比对seqs中由pairs指定的各对序列，结果的顺序与pairs相同；
+// ctx被取消时尽快停止，未全部完成时返回已完成部分的结果和ctx.Err()，未完成的结果中Alignment为nil、Score为0
+func (this *Batch) Run(ctx context.Context, seqs []*sequence.Seq, pairs [][2]int) ([]Result, error) {
+	n := this.Workers
+	if n <= 0 {
+		n = runtime.NumCPU()
+	}
+	res := make([]Result, len(pairs))
+	for k, p := range pairs {
+		res[k].I, res[k].J = p[0], p[1]
+	}
+	jobs := make(chan int)
+	done := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < n; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for k := range jobs {
+				r := &res[k]
+				r.Score, r.Alignment = this.align(seqs[r.I], seqs[r.J])
+				done <- k
+			}
+		}()
+	}
+	go func() {
+		defer close(jobs)
+		for k := range pairs {
+			select {
+			case jobs <- k:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	count := 0
+	for range done {
+		if count++; this.Progress != nil {
+			this.Progress(count, len(pairs))
+		}
+	}
+	if count < len(pairs) {
+		return res, ctx.Err()
+	}
+	return res, nil
+}
+
+// 并发地比对seqs中所有的序列对(i, j)，i<j，结果按i、j的顺序排列，其余与Run相同
+func (this *Batch) AllPairs(ctx context.Context, seqs []*sequence.Seq) ([]Result, error) {
+	pairs := make([][2]int, 0, len(seqs)*(len(seqs)-1)/2)
+	for i := range seqs {
+		for j := i + 1; j < len(seqs); j++ {
+			pairs = append(pairs, [2]int{i, j})
+		}
+	}
+	return this.Run(ctx, seqs, pairs)
+}
@@ -0,0 +1,47 @@
+package alignment
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+
+	"github.com/hydra13142/bio/sequence"
+)
+
+// 随机的DNA序列
+func randomDNA(r *rand.Rand, n int) *sequence.Seq {
+	s := make([]byte, n)
+	for i := range s {
+		s[i] = "ACGT"[r.Intn(4)]
+	}
+	p := sequence.NewForwardSeq(s)
+	p.AsDNA()
+	return p
+}
+
+func TestBatchFull(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	seqs := []*sequence.Seq{}
+	for i := 0; i < 4; i++ {
+		seqs = append(seqs, randomDNA(r, 200+r.Intn(200)))
+	}
+	gap := [2]Gap{NewGap(10, 1), NewGap(10, 1)}
+	for _, mode := range []Mode{Global, Contained, Overlap, EndsFree, Local} {
+		b := &Batch{Gap: gap, Mode: mode, Full: true, Workers: 2}
+		res, err := b.AllPairs(context.Background(), seqs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, x := range res {
+			p, q := seqs[x.I], seqs[x.J]
+			want := AlignSeq(p, q, NUC44, gap, mode)
+			a := x.Alignment
+			if x.Score != want.Score || a.Score != want.Score {
+				t.Errorf("mode %d pair %d-%d: score %v, want %v", mode, x.I, x.J, x.Score, want.Score)
+			}
+			if mode != Local && a.End != [2]int{len(p.Char), len(q.Char)} {
+				t.Errorf("mode %d pair %d-%d: end %v", mode, x.I, x.J, a.End)
+			}
+		}
+	}
+}
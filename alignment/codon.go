@@ -0,0 +1,209 @@
+package alignment
+
+import (
+	"math"
+
+	"github.com/hydra13142/bio/sequence"
+)
+
+// 翻译编码序列，DNA先转录为RNA，只适用于DNA、RNA，否则返回nil
+func translate(s *sequence.Seq) []byte {
+	switch s.Kind() {
+	case "DNA":
+		s = s.Transcript()
+	case "RNA":
+	default:
+		return nil
+	}
+	return s.Translate().Char
+}
+
+// 按照比对好的蛋白序列，将gap以密码子为单位插入对应的编码序列中（PAL2NAL方法），
+// 蛋白序列的每个字符对应编码序列的3个碱基，多余的末尾碱基（如终止密码子）被舍弃；编码序列太短时返回nil
+func ThreadCodons(protein, cds []byte) []byte {
+	t := make([]byte, 0, len(protein)*3)
+	i := 0
+	for _, c := range protein {
+		if c == '-' {
+			t = append(t, '-', '-', '-')
+			continue
+		}
+		if i+3 > len(cds) {
+			return nil
+		}
+		t = append(t, cds[i:i+3]...)
+		i += 3
+	}
+	return t
+}
+
+// 对一组比对好的蛋白序列和对应的编码序列（按下标对应）生成密码子比对，可用于msa的结果；任一序列不匹配时返回nil
+func ThreadCodonsAll(protein, cds []sequence.Sequence) []sequence.Sequence {
+	if len(protein) != len(cds) {
+		return nil
+	}
+	ans := make([]sequence.Sequence, len(cds))
+	for i := range cds {
+		t := ThreadCodons(protein[i].Char, cds[i].DeleteGaps().Char)
+		if t == nil {
+			return nil
+		}
+		ans[i] = sequence.Sequence{Name: cds[i].Name, Seq: cds[i].Seq}
+		ans[i].Char = t
+	}
+	return ans
+}
+
+// 密码子感知的编码序列比对：将两个编码序列翻译后用替换矩阵m比对蛋白序列，再将gap以密码子为单位插回核酸序列；
+// 返回蛋白比对的结果以及两个带gap的核酸序列，局部模式时核酸序列只包含比对部分的密码子；序列中原有的gap先被删除；不是DNA、RNA时返回nil
+func CodonAlign(p, q *sequence.Seq, m *Matrix, gap [2]Gap, mode Mode) (*Alignment, []byte, []byte) {
+	p, q = p.DeleteGaps(), q.DeleteGaps()
+	a, b := translate(p), translate(q)
+	if a == nil || b == nil {
+		return nil, nil, nil
+	}
+	v, o, s := Align(len(a), len(b), MatchBytes(a, b, m.Get), gap, nil, mode)
+	r := NewAlignment(v, o, s, a, b, m.Get)
+	x := ThreadCodons(r.Query, p.Char[3*r.Start[0]:])
+	y := ThreadCodons(r.Subject, q.Char[3*r.Start[1]:])
+	return r, x, y
+}
+
+// 核酸与蛋白比对中每一步的类型
+const (
+	FrameCodon  = 'M' // 一个密码子对应一个氨基酸
+	FrameInsert = 'I' // 一个密码子对应蛋白中的gap
+	FrameDelete = 'D' // 一个氨基酸对应核酸中的gap
+	FrameShift1 = '1' // 移码，跳过1个碱基
+	FrameShift2 = '2' // 移码，跳过2个碱基
+)
+
+// 核酸与蛋白的比对结果，坐标从0开始，Start、End的第一个元素为核酸上的位置，第二个为蛋白上的位置
+type FrameAlignment struct {
+	Score      float64
+	Start, End [2]int
+	Ops        []byte // 各步的类型
+	DNA        []byte // 带gap的核酸序列，移码跳过的碱基照常列出
+	Protein    []byte // 与DNA等长，每个氨基酸占3列（居中），gap为---，移码处为!
+}
+
+// 核酸与蛋白比对矩阵的方格，三个状态依次为密码子对氨基酸（含移码）、核酸多出密码子、蛋白多出氨基酸
+type frameCell struct {
+	sum  [3]float64
+	from [3]int8
+	move [3]int8 // 状态0的来源步长：3为密码子，1、2为移码
+}
+
+// 容许移码的核酸-蛋白局部比对：将核酸的密码子翻译后与蛋白用替换矩阵m计分，
+// gap[0]为核酸中添加空位（以氨基酸为单位）、gap[1]为蛋白中添加空位（以密码子为单位）的罚分，shift为每次移码的罚分；
+// 用于在基因组序列上定位含有移码或测序错误的编码区，只比对dna的正链，需要时可对反向互补序列再比对一次；
+// 使用完整的(N+1)×(M+1)矩阵回溯，内存与两个序列长度的乘积成正比，适用于基因或片段级别的序列，不适合直接比对整个基因组
+func FrameshiftAlign(dna, protein []byte, m *Matrix, gap [2]Gap, shift float64) *FrameAlignment {
+	N, M := len(dna), len(protein)
+	inf := math.Inf(-1)
+	// aa[i]为dna[i:i+3]翻译得到的氨基酸
+	aa := make([]byte, N)
+	for i := 0; i+3 <= N; i++ {
+		c := []byte{sequence.Upper(dna[i]), sequence.Upper(dna[i+1]), sequence.Upper(dna[i+2])}
+		for k := range c {
+			if c[k] == 'T' {
+				c[k] = 'U'
+			}
+		}
+		if v, ok := sequence.FromCodon[string(c)]; ok {
+			aa[i] = v
+		} else {
+			aa[i] = 'X'
+		}
+	}
+	mx := make([][]frameCell, N+1)
+	for i := range mx {
+		mx[i] = make([]frameCell, M+1)
+		for j := range mx[i] {
+			mx[i][j].sum = [3]float64{inf, inf, inf}
+		}
+	}
+	bx, by, best := 0, 0, float64(0)
+	for i := 0; i <= N; i++ {
+		for j := 0; j <= M; j++ {
+			c := &mx[i][j]
+			if i >= 3 && j >= 1 {
+				s := m.Get(aa[i-3], protein[j-1])
+				v, f := best3(mx[i-3][j-1].sum)
+				if v <= 0 {
+					c.sum[0], c.from[0], c.move[0] = s, -1, 3
+				} else {
+					c.sum[0], c.from[0], c.move[0] = v+s, f, 3
+				}
+			}
+			for k := 1; k <= 2 && k <= i; k++ {
+				if v, f := best3(mx[i-k][j].sum); v-shift > c.sum[0] {
+					c.sum[0], c.from[0], c.move[0] = v-shift, f, int8(k)
+				}
+			}
+			if i >= 3 {
+				c.sum[1], c.from[1] = extend(&AffineCell{sum: mx[i-3][j].sum}, 1, gap[1].Open, gap[1].Extend)
+			}
+			if j >= 1 {
+				c.sum[2], c.from[2] = extend(&AffineCell{sum: mx[i][j-1].sum}, 2, gap[0].Open, gap[0].Extend)
+			}
+			if c.sum[0] > best {
+				bx, by, best = i, j, c.sum[0]
+			}
+		}
+	}
+	r := &FrameAlignment{Score: best, End: [2]int{bx, by}}
+	if best <= 0 {
+		r.End = [2]int{0, 0}
+		return r
+	}
+	// 回溯
+	i, j, k := bx, by, int8(0)
+	for k >= 0 {
+		c := &mx[i][j]
+		f := c.from[k]
+		switch {
+		case k == 0 && c.move[0] == 3:
+			r.Ops = append(r.Ops, FrameCodon)
+			i, j = i-3, j-1
+		case k == 0:
+			r.Ops = append(r.Ops, '0'+byte(c.move[0]))
+			i -= int(c.move[0])
+		case k == 1:
+			r.Ops = append(r.Ops, FrameInsert)
+			i -= 3
+		default:
+			r.Ops = append(r.Ops, FrameDelete)
+			j--
+		}
+		k = f
+	}
+	for x, y := 0, len(r.Ops)-1; x < y; x, y = x+1, y-1 {
+		r.Ops[x], r.Ops[y] = r.Ops[y], r.Ops[x]
+	}
+	r.Start = [2]int{i, j}
+	for _, op := range r.Ops {
+		switch op {
+		case FrameCodon:
+			r.DNA = append(r.DNA, dna[i:i+3]...)
+			r.Protein = append(r.Protein, ' ', protein[j], ' ')
+			i, j = i+3, j+1
+		case FrameInsert:
+			r.DNA = append(r.DNA, dna[i:i+3]...)
+			r.Protein = append(r.Protein, '-', '-', '-')
+			i += 3
+		case FrameDelete:
+			r.DNA = append(r.DNA, '-', '-', '-')
+			r.Protein = append(r.Protein, ' ', protein[j], ' ')
+			j++
+		default:
+			n := int(op - '0')
+			r.DNA = append(r.DNA, dna[i:i+n]...)
+			for ; n > 0; n-- {
+				r.Protein = append(r.Protein, '!')
+			}
+			i += int(op - '0')
+		}
+	}
+	return r
+}
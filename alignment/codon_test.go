@@ -0,0 +1,31 @@
+package alignment
+
+import (
+	"testing"
+
+	"github.com/hydra13142/bio/sequence"
+)
+
+func TestCodonAlign(t *testing.T) {
+	gap := [2]Gap{NewGap(10, 1), NewGap(10, 1)}
+	for _, c := range []struct{ p, q string }{
+		{"ATGAAAGCTTGGCATTAA", "ATGAAATGGCATTAA"},
+		// 输入中原有的gap不影响结果
+		{"ATG-AAAGCT--TGGCATTAA", "ATGAAA---TGGCATTAA"},
+	} {
+		p := sequence.NewForwardSeq([]byte(c.p))
+		p.AsDNA()
+		q := sequence.NewForwardSeq([]byte(c.q))
+		q.AsDNA()
+		a, x, y := CodonAlign(p, q, BLOSUM62, gap, Global)
+		if a == nil {
+			t.Fatalf("CodonAlign(%s, %s) = nil", c.p, c.q)
+		}
+		if string(a.Query) != "MKAWH*" || string(a.Subject) != "MK-WH*" {
+			t.Errorf("CodonAlign(%s, %s) protein %s / %s", c.p, c.q, a.Query, a.Subject)
+		}
+		if string(x) != "ATGAAAGCTTGGCATTAA" || string(y) != "ATGAAA---TGGCATTAA" {
+			t.Errorf("CodonAlign(%s, %s) codons %s / %s", c.p, c.q, x, y)
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package alignment
+
+import "math"
+
+// 一个uint64中并排存放4个16位的分值，每个分值只使用低15位，最高位用来隔断借位和进位
+const (
+	laneHigh = 0x8000800080008000
+	laneLow  = 0x7FFF7FFF7FFF7FFF
+	laneOne  = 0x0001000100010001
+	laneFull = 0xFFFFFFFFFFFFFFFF
+	lanes    = 4
+)
+
+// 将一个分值复制到4个通道
+func broadcast(x int) uint64 {
+	return uint64(x) * laneOne
+}
+
+// 各通道a>=b时该通道为全1，否则为0
+func geMask(a, b uint64) uint64 {
+	return (((a | laneHigh) - b) & laneHigh) >> 15 * 0xFFFF
+}
+
+// 各通道的最大值
+func vmax(a, b uint64) uint64 {
+	m := geMask(a, b)
+	return a&m | b&^m
+}
+
+// 各通道的饱和减法，结果不小于0
+func vsubs(a, b uint64) uint64 {
+	d := (a | laneHigh) - b
+	return d & laneLow & ((d & laneHigh) >> 15 * 0xFFFF)
+}
+
+// 各通道的饱和加法，结果不大于0x7FFF
+func vadds(a, b uint64) uint64 {
+	s := a + b
+	m := (s & laneHigh) >> 15 * 0xFFFF
+	return s&laneLow&^m | laneLow&m
+}
+
+// 四舍五入为整数
+func round(x float64) int {
+	return int(math.Floor(x + 0.5))
+}
+
+// 整数打分的快速比对核，预先计算序列一（查询序列）的打分表，用于将同一个查询序列与大量序列比对时只计算分值；
+// 局部比对使用Farrar的条带（striped）算法，在一个uint64中并行计算4个16位的分值，分值溢出时自动改用标量算法；全局比对只有标量算法
+type Kernel struct {
+	query   []byte
+	matrix  *Matrix
+	gap     [2]Gap
+	open    [2]int // 整数化的gap罚分，下标含义与gap相同
+	extend  [2]int
+	endOpen [2]int
+	endExt  [2]int
+	seg     int        // 每个通道负责的查询序列长度
+	bias    int        // 使替换分值非负的偏移量
+	limit   int        // 条带算法可靠的最大分值
+	striped [][]uint64 // 按序列二的字符编号的条带打分表，每个为seg个向量
+	plain   [][]int    // 按序列二的字符编号的普通打分表
+}
+
+// 使用查询序列、替换矩阵和gap罚分创建比对核，替换分值和gap罚分都四舍五入为整数，gap的含义与NeedlemanWunschAffine相同；
+// 要求Open不小于Extend、EndOpen不小于EndExtend，否则结果可能与NeedlemanWunschAffine不同
+func NewKernel(query []byte, m *Matrix, gap [2]Gap) *Kernel {
+	k := &Kernel{query: query, matrix: m, gap: gap}
+	for x := 0; x < 2; x++ {
+		k.open[x], k.extend[x] = round(gap[x].Open), round(gap[x].Extend)
+		k.endOpen[x], k.endExt[x] = round(gap[x].EndOpen), round(gap[x].EndExtend)
+	}
+	n := len(m.Alphabet) + 1
+	k.plain = make([][]int, n)
+	top := 0
+	for c := 0; c < n; c++ {
+		k.plain[c] = make([]int, len(query))
+		for i, q := range query {
+			v := round(m.min)
+			if a, b := m.find(q), c; a >= 0 && b < n-1 {
+				v = round(m.Score[a][b])
+			}
+			k.plain[c][i] = v
+			if v < -k.bias {
+				k.bias = -v
+			}
+			if v > top {
+				top = v
+			}
+		}
+	}
+	k.seg = (len(query) + lanes - 1) / lanes
+	k.limit = 0x7FFF - top - k.bias
+	k.striped = make([][]uint64, n)
+	for c := range k.striped {
+		k.striped[c] = make([]uint64, k.seg)
+		for i := 0; i < k.seg; i++ {
+			var v uint64
+			for l := lanes - 1; l >= 0; l-- {
+				v <<= 16
+				if p := i + l*k.seg; p < len(query) {
+					v |= uint64(k.plain[c][p] + k.bias)
+				}
+			}
+			k.striped[c][i] = v
+		}
+	}
+	return k
+}
+
+// 序列二字符在打分表中的编号
+func (this *Kernel) index(c byte) int {
+	if i := this.matrix.find(c); i >= 0 {
+		return i
+	}
+	return len(this.matrix.Alphabet)
+}
+
+// 返回查询序列与t的局部比对分值，与SmithWatermanAffine的First相同
+func (this *Kernel) Local(t []byte) int {
+	n := this.seg
+	if n == 0 || len(t) == 0 {
+		return 0
+	}
+	if this.limit <= 0 {
+		return this.scalar(t, true)
+	}
+	store, load, e := make([]uint64, n), make([]uint64, n), make([]uint64, n)
+	openE, extE := broadcast(this.open[0]), broadcast(this.extend[0])
+	openF, extF := broadcast(this.open[1]), broadcast(this.extend[1])
+	bias := broadcast(this.bias)
+	var best uint64
+	for _, c := range t {
+		p := this.striped[this.index(c)]
+		var vF uint64
+		vH := store[n-1] << 16
+		load, store = store, load
+		for i := 0; i < n; i++ {
+			vH = vsubs(vadds(vH, p[i]), bias)
+			vE := e[i]
+			vH = vmax(vmax(vH, vE), vF)
+			best = vmax(best, vH)
+			store[i] = vH
+			e[i] = vmax(vsubs(vE, extE), vsubs(vH, openE))
+			vF = vmax(vsubs(vF, extF), vsubs(vH, openF))
+			vH = load[i]
+		}
+		// 修正跨越条带边界的纵向gap（lazy F）
+		vF <<= 16
+		for i := 0; geMask(vsubs(store[i], openF), vF) != laneFull; {
+			store[i] = vmax(store[i], vF)
+			e[i] = vmax(e[i], vsubs(store[i], openE))
+			vF = vsubs(vF, extF)
+			if i++; i == n {
+				i, vF = 0, vF<<16
+			}
+		}
+	}
+	max := 0
+	for l := 0; l < lanes; l++ {
+		if v := int(best >> (16 * uint(l)) & 0xFFFF); v > max {
+			max = v
+		}
+	}
+	if max >= this.limit {
+		return this.scalar(t, true)
+	}
+	return max
+}
+
+// 返回查询序列与t的全局比对分值，两端的gap按EndOpen和EndExtend罚分，与Align的Global模式相同；
+// 使用标量算法，只是省去了浮点运算和回溯，不像Local那样并行计算
+func (this *Kernel) Global(t []byte) int {
+	return this.scalar(t, false)
+}
+
+// 标量的整数动态规划，按序列二逐列计算，只保存一列
+func (this *Kernel) scalar(t []byte, local bool) int {
+	const inf = -1 << 40
+	m, w := len(this.query), len(t)
+	// gap罚分，x为0表示序列一添加空位（横向），为1表示序列二添加空位（纵向），end表示位于两端
+	pen := func(x int, end bool) (int, int) {
+		if end && !local {
+			return this.endOpen[x], this.endExt[x]
+		}
+		return this.open[x], this.extend[x]
+	}
+	H, E := make([]int, m+1), make([]int, m+1)
+	o, x := pen(1, true)
+	H[0], E[0] = 0, inf
+	for i := 1; i <= m; i++ {
+		E[i] = inf
+		if local {
+			H[i] = 0
+		} else {
+			H[i] = -o - (i-1)*x
+		}
+	}
+	best := 0
+	for j := 1; j <= w; j++ {
+		s := this.plain[this.index(t[j-1])]
+		vo, vx := pen(1, j == w)
+		diag := H[0]
+		ho, hx := pen(0, true)
+		if local {
+			H[0] = 0
+		} else {
+			E[0] = max2(H[0]-ho, E[0]-hx)
+			H[0] = E[0]
+		}
+		F := inf
+		for i := 1; i <= m; i++ {
+			ho, hx = pen(0, i == m)
+			E[i] = max2(H[i]-ho, E[i]-hx)
+			F = max2(H[i-1]-vo, F-vx)
+			v := diag + s[i-1]
+			if local && v < 0 {
+				v = 0
+			}
+			if local && v > best {
+				best = v
+			}
+			diag = H[i]
+			H[i] = max2(v, max2(E[i], F))
+		}
+	}
+	if local {
+		return best
+	}
+	return H[m]
+}
+
+// 两个整数中的较大值
+func max2(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// 先快速计算查询序列与t的局部比对分值，分值不低于threshold时再用SmithWatermanAffine回溯得到完整的比对结果，否则结果为nil
+func (this *Kernel) Screen(t []byte, threshold int) (int, *Alignment) {
+	v := this.Local(t)
+	if v < threshold || v <= 0 {
+		return v, nil
+	}
+	m := SmithWatermanAffine(len(this.query), len(t), MatchBytes(this.query, t, this.matrix.Get), this.gap, nil)
+	o, s := m.Settle()
+	return v, NewAlignment(m.First(), o, s, this.query, t, this.matrix.Get)
+}
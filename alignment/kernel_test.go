@@ -0,0 +1,123 @@
+package alignment
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func TestKernelScore(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	for it := 0; it < 300; it++ {
+		m, a := NUC44, "ACGTN"
+		if it%2 == 1 {
+			m, a = BLOSUM62, "ARNDCQEGHILKMFPSTWYVX"
+		}
+		p := randomBytes(r, 1+r.Intn(60), a)
+		q := randomBytes(r, r.Intn(80), a)
+		if it%5 == 0 {
+			// 包含查询序列，使局部比对的分值较高
+			q = append(append(randomBytes(r, 10, a), p...), randomBytes(r, 7, a)...)
+		}
+		var g [2]Gap
+		for x := range g {
+			e, ee := float64(1+r.Intn(3)), float64(r.Intn(3))
+			g[x] = Gap{e + float64(r.Intn(10)), e, ee + float64(r.Intn(4)), ee}
+		}
+		k := NewKernel(p, m, g)
+		mate := MatchBytes(p, q, m.Get)
+		want := 0.0
+		if len(q) > 0 {
+			want = SmithWatermanAffine(len(p), len(q), mate, g, nil).First()
+		}
+		if got := k.Local(q); float64(got) != want {
+			t.Fatalf("pair %d: Local = %d, want %v (%s, %s, %v)", it, got, want, p, q, g)
+		}
+		if got, want := k.Global(q), AlignScore(len(p), len(q), mate, g, nil, Global); float64(got) != want {
+			t.Fatalf("pair %d: Global = %d, want %v (%s, %s, %v)", it, got, want, p, q, g)
+		}
+	}
+}
+
+func TestKernelOverflow(t *testing.T) {
+	// 分值超出16位通道的范围时改用标量算法
+	p := randomBytes(rand.New(rand.NewSource(1)), 5000, "W")
+	k := NewKernel(p, BLOSUM62, [2]Gap{NewGap(10, 1), NewGap(10, 1)})
+	if v := k.Local(p); v != 5000*11 {
+		t.Errorf("Local = %d, want %d", v, 5000*11)
+	}
+}
+
+// 不同长度的查询序列与5000个碱基的序列比对
+var benchLengths = []int{20, 100, 500}
+
+func BenchmarkKernelLocal(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	q := randomBytes(r, 5000, "ACGT")
+	for _, n := range benchLengths {
+		p := randomBytes(r, n, "ACGT")
+		k := NewKernel(p, NUC44, [2]Gap{NewGap(10, 1), NewGap(10, 1)})
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				k.Local(q)
+			}
+		})
+	}
+}
+
+func BenchmarkSmithWatermanAffine(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	q := randomBytes(r, 5000, "ACGT")
+	for _, n := range benchLengths {
+		p := randomBytes(r, n, "ACGT")
+		mate := MatchBytes(p, q, NUC44.Get)
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				SmithWatermanAffine(len(p), len(q), mate, [2]Gap{NewGap(10, 1), NewGap(10, 1)}, nil).First()
+			}
+		})
+	}
+}
+
+// 原有的SmithWaterman（任意gap罚分函数）作为基准
+func BenchmarkSmithWaterman(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	q := randomBytes(r, 5000, "ACGT")
+	for _, n := range benchLengths {
+		p := randomBytes(r, n, "ACGT")
+		mate := MatchBytes(p, q, NUC44.Get)
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				SmithWaterman(len(p), len(q), mate, DefaultNick, DefaultCoef).First()
+			}
+		})
+	}
+}
+
+func BenchmarkKernelGlobal(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	q := randomBytes(r, 5000, "ACGT")
+	for _, n := range benchLengths {
+		p := randomBytes(r, n, "ACGT")
+		k := NewKernel(p, NUC44, [2]Gap{NewGap(10, 1), NewGap(10, 1)})
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				k.Global(q)
+			}
+		})
+	}
+}
+
+func BenchmarkAlignScoreLocal(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	q := randomBytes(r, 5000, "ACGT")
+	for _, n := range benchLengths {
+		p := randomBytes(r, n, "ACGT")
+		mate := MatchBytes(p, q, NUC44.Get)
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				AlignScore(len(p), len(q), mate, [2]Gap{NewGap(10, 1), NewGap(10, 1)}, nil, Local)
+			}
+		})
+	}
+}
@@ -0,0 +1,188 @@
+package alignment
+
+import "math"
+
+// 子问题的面积不超过该值时直接填充完整矩阵求解
+const linearBlock = 1 << 12
+
+// 从(i0,j0)按行向下计算到第i0+h行，返回最后一行各位置三个状态的最大分值，s0为路径到达(i0,j0)时的状态
+func (this *affine) forward(i0, j0, h, w int, s0 int8) [][3]float64 {
+	inf := math.Inf(-1)
+	prev, cur := make([][3]float64, w+1), make([][3]float64, w+1)
+	for i := 0; i <= h; i++ {
+		for j := 0; j <= w; j++ {
+			c := &cur[j]
+			*c = [3]float64{inf, inf, inf}
+			if i == 0 && j == 0 {
+				c[s0] = 0
+				continue
+			}
+			x, y := i0+i, j0+j
+			if i > 0 && j > 0 {
+				v, _ := best3(prev[j-1])
+				c[0] = v + this.score(x, y)
+			}
+			if i > 0 {
+				o, e := this.penalty(1, x, y)
+				c[1], _ = extend(&AffineCell{sum: prev[j]}, 1, o, e)
+			}
+			if j > 0 {
+				o, e := this.penalty(2, x, y)
+				c[2], _ = extend(&AffineCell{sum: cur[j-1]}, 2, o, e)
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev
+}
+
+// 从(i0+h,j0+w)按行向上计算到第i0+mid行，返回该行各位置在三个状态下到终点的最大分值，s1为路径到达终点时的状态，小于0时不限
+func (this *affine) backward(i0, j0, h, w, mid int, s1 int8) [][3]float64 {
+	inf := math.Inf(-1)
+	prev, cur := make([][3]float64, w+1), make([][3]float64, w+1)
+	for i := h; i >= mid; i-- {
+		for j := w; j >= 0; j-- {
+			c := &cur[j]
+			*c = [3]float64{inf, inf, inf}
+			if i == h && j == w {
+				for s := int8(0); s < 3; s++ {
+					if s1 < 0 || s == s1 {
+						c[s] = 0
+					}
+				}
+				continue
+			}
+			x, y := i0+i, j0+j
+			if i < h && j < w {
+				v := prev[j+1][0] + this.score(x+1, y+1)
+				for s := 0; s < 3; s++ {
+					c[s] = v
+				}
+			}
+			if i < h {
+				o, e := this.penalty(1, x+1, y)
+				for s := int8(0); s < 3; s++ {
+					v := prev[j][1] - o
+					if s == 1 {
+						v = prev[j][1] - e
+					}
+					if v > c[s] {
+						c[s] = v
+					}
+				}
+			}
+			if j < w {
+				o, e := this.penalty(2, x, y+1)
+				for s := int8(0); s < 3; s++ {
+					v := cur[j+1][2] - o
+					if s == 2 {
+						v = cur[j+1][2] - e
+					}
+					if v > c[s] {
+						c[s] = v
+					}
+				}
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev
+}
+
+// 用分治法求(i0,j0)到(i0+h,j0+w)的最优路径，s0、s1为起点和终点的状态，s1小于0时不限，返回对齐信息序列
+func (this *affine) divide(i0, j0, h, w int, s0, s1 int8) []byte {
+	if h <= 1 || w <= 1 || (h+1)*(w+1) <= linearBlock {
+		m := this.fill(i0, j0, h, w, s0)
+		k := s1
+		if k < 0 {
+			_, k = best3(m[h][w].sum)
+		}
+		return traceback(m, h, w, k)
+	}
+	mid := h / 2
+	f := this.forward(i0, j0, mid, w, s0)
+	b := this.backward(i0, j0, h, w, mid, s1)
+	y, k, max := 0, int8(0), math.Inf(-1)
+	for j := 0; j <= w; j++ {
+		for s := int8(0); s < 3; s++ {
+			if v := f[j][s] + b[j][s]; v > max {
+				y, k, max = j, s, v
+			}
+		}
+	}
+	s := this.divide(i0, j0, mid, y, s0, k)
+	return append(s, this.divide(i0+mid, j0+y, h-mid, w-y, k, s1)...)
+}
+
+// 线性空间的全局序列比对（Myers-Miller算法），参数与NeedlemanWunschAffine相同，返回比对分值以及与Settle相同格式的起始点和对齐信息序列，适用于很长的序列
+func LinearNeedlemanWunsch(H, W int, mate func(int, int) float64, gap [2]Gap, coef func(int, int) float64) (float64, [2]int, []byte) {
+	if mate == nil {
+		return 0, [2]int{0, 0}, nil
+	}
+	a := newAffine(H, W, mate, gap, coef, false)
+	v, _ := best3(a.forward(0, 0, H, W, 0)[W])
+	return v, [2]int{0, 0}, a.divide(0, 0, H, W, 0, -1)
+}
+
+// 线性空间地扫描局部比对的矩阵，返回最高分的终点、分值和起点
+func (this *affine) scan() (int, int, float64, [2]int) {
+	inf := math.Inf(-1)
+	prev, cur := make([]AffineCell, this.W+1), make([]AffineCell, this.W+1)
+	for j := range prev {
+		prev[j].sum = [3]float64{inf, inf, inf}
+	}
+	x, y, max, ori := 0, 0, float64(0), [2]int{0, 0}
+	for i := 1; i <= this.H; i++ {
+		cur[0].sum = [3]float64{inf, inf, inf}
+		for j := 1; j <= this.W; j++ {
+			c := &cur[j]
+			c.sum = [3]float64{inf, inf, inf}
+			s := this.score(i, j)
+			v, f := best3(prev[j-1].sum)
+			if v <= 0 {
+				c.sum[0], c.ori[0] = s, [2]int{i - 1, j - 1}
+			} else {
+				c.sum[0], c.ori[0] = v+s, prev[j-1].ori[f]
+			}
+			o, e := this.penalty(1, i, j)
+			v, f = extend(&prev[j], 1, o, e)
+			c.sum[1], c.ori[1] = v, prev[j].ori[f]
+			o, e = this.penalty(2, i, j)
+			v, f = extend(&cur[j-1], 2, o, e)
+			c.sum[2], c.ori[2] = v, cur[j-1].ori[f]
+			if c.sum[0] > max {
+				x, y, max, ori = i, j, c.sum[0], c.ori[0]
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return x, y, max, ori
+}
+
+// 线性空间的局部序列比对，参数与SmithWatermanAffine相同，先线性空间地找到最优局部比对的起点和终点，再对其间的区域做线性空间的全局比对
+func LinearSmithWaterman(H, W int, mate func(int, int) float64, gap [2]Gap, coef func(int, int) float64) (float64, [2]int, []byte) {
+	if mate == nil {
+		return 0, [2]int{0, 0}, nil
+	}
+	a := newAffine(H, W, mate, gap, coef, true)
+	x, y, max, ori := a.scan()
+	if max <= 0 {
+		return 0, [2]int{0, 0}, nil
+	}
+	a.local = false
+	return max, ori, a.divide(ori[0], ori[1], x-ori[0], y-ori[1], 0, 0)
+}
+
+// 线性空间地按照指定模式比对，参数和返回值与Align相同，全局和半全局模式使用Myers-Miller算法，局部模式使用LinearSmithWaterman
+func LinearAlign(H, W int, mate func(int, int) float64, gap [2]Gap, coef func(int, int) float64, mode Mode) (float64, [2]int, []byte) {
+	if mate == nil {
+		return 0, [2]int{0, 0}, nil
+	}
+	if mode&Local != 0 {
+		return LinearSmithWaterman(H, W, mate, gap, coef)
+	}
+	a := newAffine(H, W, mate, gap, coef, false)
+	a.free = mode
+	v, _ := best3(a.forward(0, 0, H, W, 0)[W])
+	return v, [2]int{0, 0}, a.divide(0, 0, H, W, 0, -1)
+}
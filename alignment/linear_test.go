@@ -0,0 +1,65 @@
+package alignment
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// 由字母表a中的字符组成的随机序列
+func randomBytes(r *rand.Rand, n int, a string) []byte {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = a[r.Intn(len(a))]
+	}
+	return b
+}
+
+// 按对齐信息序列重新计算比对的分值，两个序列使用相同的gap罚分且两端与中间相同
+func rescore(o [2]int, s []byte, mate func(int, int) float64, gap Gap) float64 {
+	v, i, j, last := float64(0), o[0], o[1], byte(0)
+	for _, c := range s {
+		switch c {
+		case 3:
+			v += mate(i, j)
+			i, j = i+1, j+1
+		case 1, 2:
+			if c == last {
+				v -= gap.Extend
+			} else {
+				v -= gap.Open
+			}
+			if c == 1 {
+				i++
+			} else {
+				j++
+			}
+		}
+		last = c
+	}
+	return v
+}
+
+func TestLinearAlignment(t *testing.T) {
+	r := rand.New(rand.NewSource(7))
+	g := NewGap(5, 2)
+	gap := [2]Gap{g, g}
+	for it := 0; it < 20; it++ {
+		// 长度足以让分治法拆分子问题
+		p := randomBytes(r, 100+r.Intn(150), "ACGT")
+		q := append(append(randomBytes(r, 20, "ACGT"), p[10:len(p)-10]...), randomBytes(r, 30, "ACGT")...)
+		for k := 0; k < 10; k++ {
+			q[r.Intn(len(q))] = "ACGT"[r.Intn(4)]
+		}
+		mate := MatchBytes(p, q, unit)
+		want := NeedlemanWunschAffine(len(p), len(q), mate, gap, nil).First()
+		v, o, s := LinearNeedlemanWunsch(len(p), len(q), mate, gap, nil)
+		if v != want || o != [2]int{0, 0} || rescore(o, s, mate, g) != want {
+			t.Fatalf("global %d: got %v (path %v), want %v", it, v, rescore(o, s, mate, g), want)
+		}
+		want = SmithWatermanAffine(len(p), len(q), mate, gap, nil).First()
+		v, o, s = LinearSmithWaterman(len(p), len(q), mate, gap, nil)
+		if v != want || rescore(o, s, mate, g) != want {
+			t.Fatalf("local %d: got %v (path %v), want %v", it, v, rescore(o, s, mate, g), want)
+		}
+	}
+}
@@ -0,0 +1,282 @@
+package alignment
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/hydra13142/bio/sequence"
+)
+
+// 替换矩阵，Score[i][j]为字符Alphabet[i]替换为Alphabet[j]的分值
+type Matrix struct {
+	Name     string
+	Alphabet []byte
+	Score    [][]float64
+	index    [256]int // 字符在Alphabet中的下标加1，0表示不在字母表中
+	unknown  int      // 不在字母表中的字符按该下标计分（X、N或*），-1表示使用最低分
+	min      float64
+}
+
+// 内置的常用替换矩阵，BLOSUM62和PAM250用于多肽，NUC44（NUC.4.4，即EDNAFULL）用于DNA、RNA并支持简并碱基；
+// 只内置这三个，BLOSUM45、PAM30等其他矩阵请用ReadMatrix读取NCBI提供的矩阵文件
+var (
+	BLOSUM62 = mustMatrix("BLOSUM62", blosum62)
+	PAM250   = mustMatrix("PAM250", pam250)
+	NUC44    = mustMatrix("NUC.4.4", nuc44)
+)
+
+// 使用字母表和分值表创建替换矩阵，score必须是len(alphabet)×len(alphabet)的方阵，否则返回nil；字母不区分大小写
+func NewMatrix(name string, alphabet []byte, score [][]float64) *Matrix {
+	if len(score) != len(alphabet) {
+		return nil
+	}
+	m := &Matrix{Name: name, Alphabet: make([]byte, len(alphabet)), Score: score, unknown: -1}
+	for i, c := range alphabet {
+		if len(score[i]) != len(alphabet) {
+			return nil
+		}
+		c = sequence.Upper(c)
+		m.Alphabet[i] = c
+		m.index[c] = i + 1
+	}
+	if len(score) != 0 {
+		m.min = score[0][0]
+	}
+	for i := range score {
+		for _, v := range score[i] {
+			if v < m.min {
+				m.min = v
+			}
+		}
+	}
+	for _, c := range []byte("XN*") {
+		if m.index[c] != 0 {
+			m.unknown = m.index[c] - 1
+			break
+		}
+	}
+	return m
+}
+
+// 字符在矩阵中的下标，RNA的U视为T，不在字母表中时返回unknown
+func (this *Matrix) find(c byte) int {
+	c = sequence.Upper(c)
+	if i := this.index[c]; i != 0 {
+		return i - 1
+	}
+	if i := this.index['T']; c == 'U' && i != 0 {
+		return i - 1
+	}
+	return this.unknown
+}
+
+// 返回两个字符的替换分值，不在字母表中的字符按X、N或*计分，都没有时返回矩阵中的最低分
+func (this *Matrix) Get(a, b byte) float64 {
+	i, j := this.find(a), this.find(b)
+	if i < 0 || j < 0 {
+		return this.min
+	}
+	return this.Score[i][j]
+}
+
+// 生成返回两条序列指定位置替换分值的函数，可直接作为各比对函数的mate参数
+func (this *Matrix) Mate(p, q *sequence.Seq) func(int, int) float64 {
+	return MatchBytes(p.Char, q.Char, this.Get)
+}
+
+// 根据匹配分和错配分生成支持简并碱基的核酸替换矩阵，简并碱基的分值为其代表的所有碱基组合的平均分值
+func NucleotideMatrix(match, mismatch float64) *Matrix {
+	const alphabet = "ATGCSWRYKMBVHDN"
+	code := map[byte]string{
+		'A': "A", 'T': "T", 'G': "G", 'C': "C",
+		'S': "GC", 'W': "AT", 'R': "AG", 'Y': "CT", 'K': "GT", 'M': "AC",
+		'B': "CGT", 'V': "ACG", 'H': "ACT", 'D': "AGT", 'N': "ACGT"}
+	score := make([][]float64, len(alphabet))
+	for i := range score {
+		score[i] = make([]float64, len(alphabet))
+		p := code[alphabet[i]]
+		for j := range score[i] {
+			q := code[alphabet[j]]
+			s := float64(0)
+			for k := 0; k < len(p); k++ {
+				for l := 0; l < len(q); l++ {
+					if p[k] == q[l] {
+						s += match
+					} else {
+						s += mismatch
+					}
+				}
+			}
+			score[i][j] = s / float64(len(p)*len(q))
+		}
+	}
+	return NewMatrix(fmt.Sprintf("NUC(%g,%g)", match, mismatch), []byte(alphabet), score)
+}
+
+// 读取NCBI格式的替换矩阵文件：#开头的行为注释，第一行为字母表，之后每行以字母开头，后跟该字母与字母表中各字母的替换分值
+func ReadMatrix(r io.Reader) (*Matrix, error) {
+	var (
+		alphabet []byte
+		score    [][]float64
+	)
+	buf := bufio.NewScanner(r)
+	for buf.Scan() {
+		line := strings.TrimSpace(buf.Text())
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+		field := strings.Fields(line)
+		if alphabet == nil {
+			for _, f := range field {
+				if len(f) != 1 {
+					return nil, fmt.Errorf("Need single letters in header:%q", line)
+				}
+				alphabet = append(alphabet, sequence.Upper(f[0]))
+			}
+			score = make([][]float64, len(alphabet))
+			continue
+		}
+		if len(field[0]) != 1 || len(field) != len(alphabet)+1 {
+			return nil, fmt.Errorf("Need a letter and %d scores:%q", len(alphabet), line)
+		}
+		i := strings.IndexByte(string(alphabet), sequence.Upper(field[0][0]))
+		if i < 0 || score[i] != nil {
+			return nil, fmt.Errorf("Unknown or repeated row:%q", line)
+		}
+		score[i] = make([]float64, len(alphabet))
+		for j, f := range field[1:] {
+			v, err := strconv.ParseFloat(f, 64)
+			if err != nil {
+				return nil, err
+			}
+			score[i][j] = v
+		}
+	}
+	if err := buf.Err(); err != nil {
+		return nil, err
+	}
+	for i, s := range score {
+		if s == nil {
+			return nil, fmt.Errorf("Missing row:%q", alphabet[i])
+		}
+	}
+	if alphabet == nil {
+		return nil, fmt.Errorf("Empty matrix")
+	}
+	return NewMatrix("", alphabet, score), nil
+}
+
+// 以NCBI格式写出替换矩阵
+func (this *Matrix) Write(w io.Writer) error {
+	if this.Name != "" {
+		if _, err := fmt.Fprintf(w, "# %s\n", this.Name); err != nil {
+			return err
+		}
+	}
+	line := make([]string, 0, len(this.Alphabet)+1)
+	line = append(line, " ")
+	for _, c := range this.Alphabet {
+		line = append(line, fmt.Sprintf("%3c", c))
+	}
+	if _, err := fmt.Fprintln(w, strings.Join(line, " ")); err != nil {
+		return err
+	}
+	for i, c := range this.Alphabet {
+		line = append(line[:0], string(c))
+		for _, v := range this.Score[i] {
+			line = append(line, fmt.Sprintf("%3s", strconv.FormatFloat(v, 'g', -1, 64)))
+		}
+		if _, err := fmt.Fprintln(w, strings.Join(line, " ")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 解析内置的矩阵文本
+func mustMatrix(name, text string) *Matrix {
+	m, err := ReadMatrix(strings.NewReader(text))
+	if err != nil {
+		panic(err)
+	}
+	m.Name = name
+	return m
+}
+
+const blosum62 = `
+   A  R  N  D  C  Q  E  G  H  I  L  K  M  F  P  S  T  W  Y  V  B  Z  X  *
+A  4 -1 -2 -2  0 -1 -1  0 -2 -1 -1 -1 -1 -2 -1  1  0 -3 -2  0 -2 -1  0 -4
+R -1  5  0 -2 -3  1  0 -2  0 -3 -2  2 -1 -3 -2 -1 -1 -3 -2 -3 -1  0 -1 -4
+N -2  0  6  1 -3  0  0  0  1 -3 -3  0 -2 -3 -2  1  0 -4 -2 -3  3  0 -1 -4
+D -2 -2  1  6 -3  0  2 -1 -1 -3 -4 -1 -3 -3 -1  0 -1 -4 -3 -3  4  1 -1 -4
+C  0 -3 -3 -3  9 -3 -4 -3 -3 -1 -1 -3 -1 -2 -3 -1 -1 -2 -2 -1 -3 -3 -2 -4
+Q -1  1  0  0 -3  5  2 -2  0 -3 -2  1  0 -3 -1  0 -1 -2 -1 -2  0  3 -1 -4
+E -1  0  0  2 -4  2  5 -2  0 -3 -3  1 -2 -3 -1  0 -1 -3 -2 -2  1  4 -1 -4
+G  0 -2  0 -1 -3 -2 -2  6 -2 -4 -4 -2 -3 -3 -2  0 -2 -2 -3 -3 -1 -2 -1 -4
+H -2  0  1 -1 -3  0  0 -2  8 -3 -3 -1 -2 -1 -2 -1 -2 -2  2 -3  0  0 -1 -4
+I -1 -3 -3 -3 -1 -3 -3 -4 -3  4  2 -3  1  0 -3 -2 -1 -3 -1  3 -3 -3 -1 -4
+L -1 -2 -3 -4 -1 -2 -3 -4 -3  2  4 -2  2  0 -3 -2 -1 -2 -1  1 -4 -3 -1 -4
+K -1  2  0 -1 -3  1  1 -2 -1 -3 -2  5 -1 -3 -1  0 -1 -3 -2 -2  0  1 -1 -4
+M -1 -1 -2 -3 -1  0 -2 -3 -2  1  2 -1  5  0 -2 -1 -1 -1 -1  1 -3 -1 -1 -4
+F -2 -3 -3 -3 -2 -3 -3 -3 -1  0  0 -3  0  6 -4 -2 -2  1  3 -1 -3 -3 -1 -4
+P -1 -2 -2 -1 -3 -1 -1 -2 -2 -3 -3 -1 -2 -4  7 -1 -1 -4 -3 -2 -2 -1 -2 -4
+S  1 -1  1  0 -1  0  0  0 -1 -2 -2  0 -1 -2 -1  4  1 -3 -2 -2  0  0  0 -4
+T  0 -1  0 -1 -1 -1 -1 -2 -2 -1 -1 -1 -1 -2 -1  1  5 -2 -2  0 -1 -1  0 -4
+W -3 -3 -4 -4 -2 -2 -3 -2 -2 -3 -2 -3 -1  1 -4 -3 -2 11  2 -3 -4 -3 -2 -4
+Y -2 -2 -2 -3 -2 -1 -2 -3  2 -1 -1 -2 -1  3 -3 -2 -2  2  7 -1 -3 -2 -1 -4
+V  0 -3 -3 -3 -1 -2 -2 -3 -3  3  1 -2  1 -1 -2 -2  0 -3 -1  4 -3 -2 -1 -4
+B -2 -1  3  4 -3  0  1 -1  0 -3 -4  0 -3 -3 -2  0 -1 -4 -3 -3  4  1 -1 -4
+Z -1  0  0  1 -3  3  4 -2  0 -3 -3  1 -1 -3 -1  0 -1 -3 -2 -2  1  4 -1 -4
+X  0 -1 -1 -1 -2 -1 -1 -1 -1 -1 -1 -1 -1 -1 -2  0  0 -2 -1 -1 -1 -1 -1 -4
+* -4 -4 -4 -4 -4 -4 -4 -4 -4 -4 -4 -4 -4 -4 -4 -4 -4 -4 -4 -4 -4 -4 -4  1
+`
+
+const pam250 = `
+   A  R  N  D  C  Q  E  G  H  I  L  K  M  F  P  S  T  W  Y  V  B  Z  X  *
+A  2 -2  0  0 -2  0  0  1 -1 -1 -2 -1 -1 -3  1  1  1 -6 -3  0  0  0  0 -8
+R -2  6  0 -1 -4  1 -1 -3  2 -2 -3  3  0 -4  0  0 -1  2 -4 -2 -1  0 -1 -8
+N  0  0  2  2 -4  1  1  0  2 -2 -3  1 -2 -3  0  1  0 -4 -2 -2  2  1  0 -8
+D  0 -1  2  4 -5  2  3  1  1 -2 -4  0 -3 -6 -1  0  0 -7 -4 -2  3  3 -1 -8
+C -2 -4 -4 -5 12 -5 -5 -3 -3 -2 -6 -5 -5 -4 -3  0 -2 -8  0 -2 -4 -5 -3 -8
+Q  0  1  1  2 -5  4  2 -1  3 -2 -2  1 -1 -5  0 -1 -1 -5 -4 -2  1  3 -1 -8
+E  0 -1  1  3 -5  2  4  0  1 -2 -3  0 -2 -5 -1  0  0 -7 -4 -2  3  3 -1 -8
+G  1 -3  0  1 -3 -1  0  5 -2 -3 -4 -2 -3 -5  0  1  0 -7 -5 -1  0  0 -1 -8
+H -1  2  2  1 -3  3  1 -2  6 -2 -2  0 -2 -2  0 -1 -1 -3  0 -2  1  2 -1 -8
+I -1 -2 -2 -2 -2 -2 -2 -3 -2  5  2 -2  2  1 -2 -1  0 -5 -1  4 -2 -2 -1 -8
+L -2 -3 -3 -4 -6 -2 -3 -4 -2  2  6 -3  4  2 -3 -3 -2 -2 -1  2 -3 -3 -1 -8
+K -1  3  1  0 -5  1  0 -2  0 -2 -3  5  0 -5 -1  0  0 -3 -4 -2  1  0 -1 -8
+M -1  0 -2 -3 -5 -1 -2 -3 -2  2  4  0  6  0 -2 -2 -1 -4 -2  2 -2 -2 -1 -8
+F -3 -4 -3 -6 -4 -5 -5 -5 -2  1  2 -5  0  9 -5 -3 -3  0  7 -1 -4 -5 -2 -8
+P  1  0  0 -1 -3  0 -1  0  0 -2 -3 -1 -2 -5  6  1  0 -6 -5 -1 -1  0 -1 -8
+S  1  0  1  0  0 -1  0  1 -1 -1 -3  0 -2 -3  1  2  1 -2 -3 -1  0  0  0 -8
+T  1 -1  0  0 -2 -1  0  0 -1  0 -2  0 -1 -3  0  1  3 -5 -3  0  0 -1  0 -8
+W -6  2 -4 -7 -8 -5 -7 -7 -3 -5 -2 -3 -4  0 -6 -2 -5 17  0 -6 -5 -6 -4 -8
+Y -3 -4 -2 -4  0 -4 -4 -5  0 -1 -1 -4 -2  7 -5 -3 -3  0 10 -2 -3 -4 -2 -8
+V  0 -2 -2 -2 -2 -2 -2 -1 -2  4  2 -2  2 -1 -1 -1  0 -6 -2  4 -2 -2 -1 -8
+B  0 -1  2  3 -4  1  3  0  1 -2 -3  1 -2 -4 -1  0  0 -5 -3 -2  3  2 -1 -8
+Z  0  0  1  3 -5  3  3  0  2 -2 -3  0 -2 -5  0  0 -1 -6 -4 -2  2  3 -1 -8
+X  0 -1  0 -1 -3 -1 -1 -1 -1 -1 -1 -1 -1 -2 -1  0  0 -4 -2 -1 -1 -1 -1 -8
+* -8 -8 -8 -8 -8 -8 -8 -8 -8 -8 -8 -8 -8 -8 -8 -8 -8 -8 -8 -8 -8 -8 -8  1
+`
+
+const nuc44 = `
+    A   T   G   C   S   W   R   Y   K   M   B   V   H   D   N
+A   5  -4  -4  -4  -4   1   1  -4  -4   1  -4  -1  -1  -1  -2
+T  -4   5  -4  -4  -4   1  -4   1   1  -4  -1  -4  -1  -1  -2
+G  -4  -4   5  -4   1  -4   1  -4   1  -4  -1  -1  -4  -1  -2
+C  -4  -4  -4   5   1  -4  -4   1  -4   1  -1  -1  -1  -4  -2
+S  -4  -4   1   1  -1  -4  -2  -2  -2  -2  -1  -1  -3  -3  -1
+W   1   1  -4  -4  -4  -1  -2  -2  -2  -2  -3  -3  -1  -1  -1
+R   1  -4   1  -4  -2  -2  -1  -4  -2  -2  -3  -1  -3  -1  -1
+Y  -4   1  -4   1  -2  -2  -4  -1  -2  -2  -1  -3  -1  -3  -1
+K  -4   1   1  -4  -2  -2  -2  -2  -1  -4  -1  -3  -3  -1  -1
+M   1  -4  -4   1  -2  -2  -2  -2  -4  -1  -3  -1  -1  -3  -1
+B  -4  -1  -1  -1  -1  -3  -3  -1  -1  -3  -1  -2  -2  -2  -1
+V  -1  -4  -1  -1  -1  -3  -1  -3  -3  -1  -2  -1  -2  -2  -1
+H  -1  -1  -4  -1  -3  -1  -3  -1  -3  -1  -2  -2  -1  -2  -1
+D  -1  -1  -1  -4  -3  -1  -1  -3  -1  -3  -2  -2  -2  -1  -1
+N  -2  -2  -2  -2  -1  -1  -1  -1  -1  -1  -1  -1  -1  -1  -1
+`
@@ -0,0 +1,56 @@
+package alignment
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNewMatrixMin(t *testing.T) {
+	m := NewMatrix("test", []byte("AB"), [][]float64{{-3, 1}, {1, 2}})
+	if m == nil {
+		t.Fatal("NewMatrix returned nil")
+	}
+	if v := m.Get('A', 'Z'); v != -3 {
+		t.Errorf("Get(A, Z) = %v, want -3", v)
+	}
+	if v := m.Get('?', '?'); v != -3 {
+		t.Errorf("Get(?, ?) = %v, want -3", v)
+	}
+}
+
+// testdata/BLOSUM62为NCBI发布的矩阵文件，带有#开头的注释
+func TestReadMatrixFile(t *testing.T) {
+	f, err := os.Open("testdata/BLOSUM62")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	m, err := ReadMatrix(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(m.Alphabet) != string(BLOSUM62.Alphabet) || !reflect.DeepEqual(m.Score, BLOSUM62.Score) {
+		t.Fatal("matrix read from testdata/BLOSUM62 differs from BLOSUM62")
+	}
+	for _, c := range []struct {
+		a, b byte
+		v    float64
+	}{{'W', 'W', 11}, {'a', 'r', -1}, {'C', 'c', 9}, {'J', 'J', -1}, {'*', 'A', -4}} {
+		if v := m.Get(c.a, c.b); v != c.v {
+			t.Errorf("Get(%c, %c) = %v, want %v", c.a, c.b, v, c.v)
+		}
+	}
+	var b bytes.Buffer
+	if err = m.Write(&b); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := ReadMatrix(&b); err != nil || !reflect.DeepEqual(n.Score, m.Score) {
+		t.Errorf("Write and ReadMatrix round trip failed: %v", err)
+	}
+	if _, err = ReadMatrix(strings.NewReader("  A  C\nA  1\nC -1  1\n")); err == nil {
+		t.Error("ReadMatrix accepted a short row")
+	}
+}
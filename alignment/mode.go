@@ -0,0 +1,61 @@
+package alignment
+
+// 比对模式，除Local外的标志可以组合使用，表示对应位置的end gap不罚分
+type Mode int
+
+const (
+	// 真正的全局比对，两个序列都完整参与比对，两端的gap按Gap.EndOpen和Gap.EndExtend罚分
+	Global Mode = 0
+	// 序列一开头的gap不罚分，即序列二的开头可以悬空
+	FreeStart1 Mode = 1 << (iota - 1)
+	// 序列一末尾的gap不罚分，即序列二的末尾可以悬空
+	FreeEnd1
+	// 序列二开头的gap不罚分，即序列一的开头可以悬空
+	FreeStart2
+	// 序列二末尾的gap不罚分，即序列一的末尾可以悬空
+	FreeEnd2
+	// 局部比对，与其他标志同时使用时其他标志被忽略
+	Local
+
+	// 序列一完整地比对到序列二的内部，如将引物、探针或测序读段比对到参考序列上
+	Contained = FreeStart1 | FreeEnd1
+	// 序列二完整地比对到序列一的内部
+	Containing = FreeStart2 | FreeEnd2
+	// 序列一的末尾与序列二的开头重叠，如拼接测序读段
+	Overlap = FreeEnd1 | FreeStart2
+	// 两端的gap都不罚分，与NeedlemanWunsch的行为相同
+	EndsFree = FreeStart1 | FreeEnd1 | FreeStart2 | FreeEnd2
+)
+
+// 按照指定的模式使用仿射gap罚分进行序列比对，参数与NeedlemanWunschAffine相同，返回比对分值以及与Settle相同格式的起始点和对齐信息序列；
+// 全局和半全局模式的起始点总是[0, 0]，对齐信息序列覆盖两个序列的全部字符，悬空的部分以gap补齐；局部模式只包含局部比对的部分
+func Align(H, W int, mate func(int, int) float64, gap [2]Gap, coef func(int, int) float64, mode Mode) (float64, [2]int, []byte) {
+	if mate == nil {
+		return 0, [2]int{0, 0}, nil
+	}
+	if mode&Local != 0 {
+		m := SmithWatermanAffine(H, W, mate, gap, coef)
+		o, s := m.Settle()
+		return m.First(), o, s
+	}
+	a := newAffine(H, W, mate, gap, coef, false)
+	a.free = mode
+	m := AffineNW(a.fill(0, 0, H, W, 0))
+	o, s := m.Settle()
+	return m.First(), o, s
+}
+
+// 只计算按照指定模式比对的分值而不回溯，参数与Align相同，只使用线性大小的内存
+func AlignScore(H, W int, mate func(int, int) float64, gap [2]Gap, coef func(int, int) float64, mode Mode) float64 {
+	if mate == nil {
+		return 0
+	}
+	if mode&Local != 0 {
+		_, _, max, _ := newAffine(H, W, mate, gap, coef, true).scan()
+		return max
+	}
+	a := newAffine(H, W, mate, gap, coef, false)
+	a.free = mode
+	v, _ := best3(a.forward(0, 0, H, W, 0)[W])
+	return v
+}
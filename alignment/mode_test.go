@@ -0,0 +1,45 @@
+package alignment
+
+import "testing"
+
+func TestAlignModes(t *testing.T) {
+	gap := [2]Gap{NewGap(2, 1), NewGap(2, 1)}
+	cases := []struct {
+		name   string
+		p, q   string
+		mode   Mode
+		score  float64
+		origin [2]int
+		path   []byte
+	}{
+		// ACGT / AC-T：3个匹配减去一个长度为1的gap
+		{"global", "ACGT", "ACT", Global, 1, [2]int{0, 0}, []byte{3, 3, 1, 3}},
+		// --ACG-- / TTACGTT：两端各一个长度为2的gap
+		{"global overhang", "ACG", "TTACGTT", Global, -3, [2]int{0, 0}, []byte{2, 2, 3, 3, 3, 2, 2}},
+		// 只比对中间的ACG
+		{"local", "TTACGTT", "GGACGGG", Local, 3, [2]int{2, 2}, []byte{3, 3, 3}},
+		// 序列一完整地比对到序列二内部，序列二两端悬空不罚分
+		{"semi-global", "ACG", "TTACGTT", Contained, 3, [2]int{0, 0}, []byte{2, 2, 3, 3, 3, 2, 2}},
+		// 序列二完整地比对到序列一内部
+		{"containing", "TTACGTT", "ACG", Containing, 3, [2]int{0, 0}, []byte{1, 1, 3, 3, 3, 1, 1}},
+		// 序列一的末尾ACG与序列二的开头ACG重叠
+		{"overlap", "TTTACG", "ACGAAA", Overlap, 3, [2]int{0, 0}, []byte{1, 1, 1, 3, 3, 3, 2, 2, 2}},
+		// 序列一的开头与序列二的末尾重叠，两端gap都不罚分
+		{"free end gaps", "ACGTT", "GGACG", EndsFree, 3, [2]int{0, 0}, []byte{2, 2, 3, 3, 3, 1, 1}},
+		// 只有序列二开头悬空不罚分，序列一末尾的T对应的gap照常罚分
+		{"free start", "ACGT", "GGACG", FreeStart1, 3 - 2, [2]int{0, 0}, []byte{2, 2, 3, 3, 3, 1}},
+	}
+	for _, c := range cases {
+		mate := MatchBytes([]byte(c.p), []byte(c.q), unit)
+		v, o, s := Align(len(c.p), len(c.q), mate, gap, nil, c.mode)
+		if v != c.score || o != c.origin || string(s) != string(c.path) {
+			t.Errorf("%s: got %v %v %v, want %v %v %v", c.name, v, o, s, c.score, c.origin, c.path)
+		}
+		if w, x, y := LinearAlign(len(c.p), len(c.q), mate, gap, nil, c.mode); w != c.score || x != c.origin || string(y) != string(c.path) {
+			t.Errorf("%s: LinearAlign %v %v %v, want %v %v %v", c.name, w, x, y, c.score, c.origin, c.path)
+		}
+		if w := AlignScore(len(c.p), len(c.q), mate, gap, nil, c.mode); w != c.score {
+			t.Errorf("%s: AlignScore %v, want %v", c.name, w, c.score)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package alignment
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+
+	"github.com/hydra13142/bio/sequence"
+)
+
+// 两条序列的比对结果，坐标从0开始，Start为比对的起点，End为比对终点的后一个位置
+type Alignment struct {
+	Score      float64
+	Start, End [2]int
+	Path       []byte // 对齐信息序列，编码与Settle相同
+	Query      []byte // 序列一比对部分的带gap序列
+	Subject    []byte // 序列二比对部分的带gap序列
+	Match      []byte // 中间的匹配行，|为相同，:为替换分值为正，.为其余错配，空格为gap
+	Identity   int    // 相同字符的列数
+	Similarity int    // 相同或替换分值为正的列数
+	Gaps       int    // 含gap的列数
+}
+
+// 根据比对分值、起始点、对齐信息序列和原始的两个序列生成比对结果，f为字符的替换分值函数，为nil时相似只包括相同
+func NewAlignment(score float64, o [2]int, s []byte, p, q []byte, f func(byte, byte) float64) *Alignment {
+	a := &Alignment{Score: score, Start: o, Path: s}
+	a.Query, a.Subject = AlignBytes(o, s, p, q)
+	a.Match = make([]byte, len(s))
+	i, j := o[0], o[1]
+	for k, c := range s {
+		switch c {
+		case 1:
+			a.Match[k] = ' '
+			a.Gaps++
+			i++
+		case 2:
+			a.Match[k] = ' '
+			a.Gaps++
+			j++
+		default:
+			x, y := sequence.Upper(p[i]), sequence.Upper(q[j])
+			switch {
+			case x == y:
+				a.Match[k] = '|'
+				a.Identity++
+				a.Similarity++
+			case f != nil && f(x, y) > 0:
+				a.Match[k] = ':'
+				a.Similarity++
+			default:
+				a.Match[k] = '.'
+			}
+			i, j = i+1, j+1
+		}
+	}
+	a.End = [2]int{i, j}
+	return a
+}
+
+// 使用替换矩阵m和仿射gap罚分，按指定模式比对两个序列并返回比对结果
+func AlignSeq(p, q *sequence.Seq, m *Matrix, gap [2]Gap, mode Mode) *Alignment {
+	v, o, s := Align(len(p.Char), len(q.Char), m.Mate(p, q), gap, nil, mode)
+	return NewAlignment(v, o, s, p.Char, q.Char, m.Get)
+}
+
+// 比对的长度（列数）
+func (this *Alignment) Length() int {
+	return len(this.Path)
+}
+
+// 返回n占比对长度的百分比
+func (this *Alignment) percent(n int) float64 {
+	if len(this.Path) == 0 {
+		return 0
+	}
+	return 100 * float64(n) / float64(len(this.Path))
+}
+
+// 相同字符列的百分比
+func (this *Alignment) PercentIdentity() float64 {
+	return this.percent(this.Identity)
+}
+
+// 相似字符列的百分比
+func (this *Alignment) PercentSimilarity() float64 {
+	return this.percent(this.Similarity)
+}
+
+// 含gap列的百分比
+func (this *Alignment) PercentGaps() float64 {
+	return this.percent(this.Gaps)
+}
+
+// 返回CIGAR字符串，以序列二为参考序列：I为序列一多出的字符，D为序列一缺少的字符；
+// extended为false时比对列都记为M，为true时相同记为=，不同记为X
+func (this *Alignment) CIGAR(extended bool) string {
+	var buf []byte
+	last, n := byte(0), 0
+	for k, c := range this.Path {
+		var op byte
+		switch c {
+		case 1:
+			op = 'I'
+		case 2:
+			op = 'D'
+		default:
+			switch {
+			case !extended:
+				op = 'M'
+			case this.Match[k] == '|':
+				op = '='
+			default:
+				op = 'X'
+			}
+		}
+		if op != last && n != 0 {
+			buf = append(strconv.AppendInt(buf, int64(n), 10), last)
+			n = 0
+		}
+		last = op
+		n++
+	}
+	if n != 0 {
+		buf = append(strconv.AppendInt(buf, int64(n), 10), last)
+	}
+	return string(buf)
+}
+
+// 返回EMBOSS风格的比对文本，name1、name2为两个序列的名字，width为每行显示的列数，行首和行尾的坐标从1开始
+func (this *Alignment) Format(name1, name2 string, width int) string {
+	if width <= 0 {
+		width = 50
+	}
+	l := len(name1)
+	if len(name2) > l {
+		l = len(name2)
+	}
+	if l < 13 {
+		l = 13
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# Length:     %d\n", len(this.Path))
+	fmt.Fprintf(&buf, "# Identity:   %d/%d (%.1f%%)\n", this.Identity, len(this.Path), this.PercentIdentity())
+	fmt.Fprintf(&buf, "# Similarity: %d/%d (%.1f%%)\n", this.Similarity, len(this.Path), this.PercentSimilarity())
+	fmt.Fprintf(&buf, "# Gaps:       %d/%d (%.1f%%)\n", this.Gaps, len(this.Path), this.PercentGaps())
+	fmt.Fprintf(&buf, "# Score:      %g\n", this.Score)
+	i, j := this.Start[0], this.Start[1]
+	for k := 0; k < len(this.Path); k += width {
+		e := k + width
+		if e > len(this.Path) {
+			e = len(this.Path)
+		}
+		m, n := i, j
+		for _, c := range this.Path[k:e] {
+			if c != 2 {
+				m++
+			}
+			if c != 1 {
+				n++
+			}
+		}
+		buf.WriteByte('\n')
+		fmt.Fprintf(&buf, "%-*s %6d %s %6d\n", l, name1, i+1, this.Query[k:e], m)
+		fmt.Fprintf(&buf, "%-*s %6s %s\n", l, "", "", this.Match[k:e])
+		fmt.Fprintf(&buf, "%-*s %6d %s %6d\n", l, name2, j+1, this.Subject[k:e], n)
+		i, j = m, n
+	}
+	return buf.String()
+}
+
+// 使用默认的名字和宽度返回比对文本
+func (this *Alignment) String() string {
+	return this.Format("seq1", "seq2", 50)
+}
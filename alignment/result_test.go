@@ -0,0 +1,42 @@
+package alignment
+
+import "testing"
+
+func TestNewAlignment(t *testing.T) {
+	// AAGCTA / AAG-TT：4个相同、1个gap、1个错配
+	p, q := []byte("AAGCTA"), []byte("AAGTT")
+	a := NewAlignment(2, [2]int{0, 0}, []byte{3, 3, 3, 1, 3, 3}, p, q, unit)
+	if string(a.Query) != "AAGCTA" || string(a.Subject) != "AAG-TT" || string(a.Match) != "||| |." {
+		t.Errorf("got %s / %s / %s", a.Query, a.Match, a.Subject)
+	}
+	if a.Identity != 4 || a.Similarity != 4 || a.Gaps != 1 || a.End != [2]int{6, 5} {
+		t.Errorf("got identity %d, similarity %d, gaps %d, end %v", a.Identity, a.Similarity, a.Gaps, a.End)
+	}
+	if s := a.CIGAR(false); s != "3M1I2M" {
+		t.Errorf("CIGAR(false) = %s, want 3M1I2M", s)
+	}
+	if s := a.CIGAR(true); s != "3=1I1=1X" {
+		t.Errorf("CIGAR(true) = %s, want 3=1I1=1X", s)
+	}
+	want := "# Length:     6\n" +
+		"# Identity:   4/6 (66.7%)\n" +
+		"# Similarity: 4/6 (66.7%)\n" +
+		"# Gaps:       1/6 (16.7%)\n" +
+		"# Score:      2\n" +
+		"\n" +
+		"query              1 AAGC      4\n" +
+		"                     ||| \n" +
+		"subject            1 AAG-      3\n" +
+		"\n" +
+		"query              5 TA      6\n" +
+		"                     |.\n" +
+		"subject            4 TT      5\n"
+	if s := a.Format("query", "subject", 4); s != want {
+		t.Errorf("Format =\n%s\nwant\n%s", s, want)
+	}
+	// 局部比对从序列中间开始，坐标从1开始计数
+	b := NewAlignment(3, [2]int{2, 1}, []byte{3, 2, 3, 3}, []byte("TTACG"), []byte("GAGCG"), nil)
+	if string(b.Query) != "A-CG" || string(b.Subject) != "AGCG" || b.End != [2]int{5, 5} || b.CIGAR(false) != "1M1D2M" {
+		t.Errorf("local: got %s / %s, end %v, CIGAR %s", b.Query, b.Subject, b.End, b.CIGAR(false))
+	}
+}
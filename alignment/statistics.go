@@ -0,0 +1,224 @@
+package alignment
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/hydra13142/bio/sequence"
+)
+
+// Karlin-Altschul统计参数，H为相对熵（nats），用于将局部比对的原始分值换算为比特分值和期望值
+type Karlin struct {
+	Lambda, K, H float64
+}
+
+// Robinson & Robinson统计的氨基酸背景频率，与BLAST使用的相同
+var Robinson = map[byte]float64{
+	'A': 0.07805, 'R': 0.05129, 'N': 0.04487, 'D': 0.05364, 'C': 0.01925,
+	'Q': 0.04264, 'E': 0.06295, 'G': 0.07377, 'H': 0.02199, 'I': 0.05142,
+	'L': 0.09019, 'K': 0.05744, 'M': 0.02243, 'F': 0.03856, 'P': 0.05203,
+	'S': 0.07120, 'T': 0.05841, 'W': 0.01330, 'Y': 0.03216, 'V': 0.06441}
+
+// 四种碱基等概率的背景频率
+var UniformDNA = map[byte]float64{'A': 0.25, 'C': 0.25, 'G': 0.25, 'T': 0.25}
+
+// 统计序列的字符组成作为背景频率，小写字母计入大写，gap不计入
+func Background(s []byte) map[byte]float64 {
+	f := map[byte]float64{}
+	n := 0
+	for _, c := range s {
+		if c == '-' {
+			continue
+		}
+		f[sequence.Upper(c)]++
+		n++
+	}
+	for c := range f {
+		f[c] /= float64(n)
+	}
+	return f
+}
+
+// 两个序列中各字符出现的概率乘积以及对应的整数替换分值
+type scorePair struct {
+	p float64
+	s int
+}
+
+// 按背景频率计算各替换分值出现的概率，频率会先归一化
+func (this *Matrix) distribution(p, q map[byte]float64) []scorePair {
+	sp, sq := float64(0), float64(0)
+	for _, v := range p {
+		sp += v
+	}
+	for _, v := range q {
+		sq += v
+	}
+	d := []scorePair{}
+	for a, x := range p {
+		for b, y := range q {
+			if x > 0 && y > 0 {
+				d = append(d, scorePair{x / sp * y / sq, round(this.Get(a, b))})
+			}
+		}
+	}
+	return d
+}
+
+// 计算无gap局部比对的Karlin-Altschul参数，p、q为两个序列的背景频率，替换分值四舍五入为整数；
+// 期望分值不为负或没有正的分值时返回的参数都是NaN；有gap的比对没有解析解，请使用EstimateKarlin
+func KarlinAltschul(m *Matrix, p, q map[byte]float64) Karlin {
+	nan := Karlin{math.NaN(), math.NaN(), math.NaN()}
+	d := m.distribution(p, q)
+	lo, hi, mean := 0, 0, float64(0)
+	for _, x := range d {
+		mean += x.p * float64(x.s)
+		if x.s < lo {
+			lo = x.s
+		}
+		if x.s > hi {
+			hi = x.s
+		}
+	}
+	if mean >= 0 || hi <= 0 {
+		return nan
+	}
+	f := func(l float64) float64 {
+		s := float64(0)
+		for _, x := range d {
+			s += x.p * math.Exp(l*float64(x.s))
+		}
+		return s - 1
+	}
+	// 求解 Σ p q exp(λs) = 1 的正根
+	a, b := float64(0), float64(0.5)
+	for f(b) <= 0 {
+		a, b = b, b*2
+	}
+	for i := 0; i < 100; i++ {
+		if c := (a + b) / 2; f(c) > 0 {
+			b = c
+		} else {
+			a = c
+		}
+	}
+	l := (a + b) / 2
+	h := float64(0)
+	for _, x := range d {
+		h += x.p * float64(x.s) * math.Exp(l*float64(x.s))
+	}
+	h *= l
+	// 单步分值的分布，下标为分值减去lo
+	one := make([]float64, hi-lo+1)
+	g := 0
+	for _, x := range d {
+		one[x.s-lo] += x.p
+		if x.p > 0 {
+			g = gcd(g, x.s)
+		}
+	}
+	// σ = Σ 1/k (Σ_{j<0} P(S_k=j) exp(λj) + P(S_k>=0))
+	sigma, cur := float64(0), one
+	for k := 1; k <= 200; k++ {
+		t := float64(0)
+		for i, v := range cur {
+			if j := i + k*lo; j < 0 {
+				t += v * math.Exp(l*float64(j))
+			} else {
+				t += v
+			}
+		}
+		sigma += t / float64(k)
+		if t/float64(k) < 1e-12 {
+			break
+		}
+		cur = convolve(cur, one)
+	}
+	dg := float64(g)
+	k := dg * l * math.Exp(-2*sigma) / (h * (1 - math.Exp(-l*dg)))
+	return Karlin{l, k, h}
+}
+
+// 最大公约数，结果非负
+func gcd(a, b int) int {
+	if a < 0 {
+		a = -a
+	}
+	if b < 0 {
+		b = -b
+	}
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+// 两个分布的卷积
+func convolve(a, b []float64) []float64 {
+	c := make([]float64, len(a)+len(b)-1)
+	for i, x := range a {
+		if x == 0 {
+			continue
+		}
+		for j, y := range b {
+			c[i+j] += x * y
+		}
+	}
+	return c
+}
+
+// 用随机序列比对的分值按极值分布（Gumbel分布）的矩估计拟合Karlin-Altschul参数，m、n为随机序列的长度，适用于有gap的比对；
+// 分值少于两个时返回的参数都是NaN，H总是NaN
+func EstimateKarlin(scores []float64, m, n int) Karlin {
+	if len(scores) < 2 {
+		return Karlin{math.NaN(), math.NaN(), math.NaN()}
+	}
+	mean, v := float64(0), float64(0)
+	for _, s := range scores {
+		mean += s
+	}
+	mean /= float64(len(scores))
+	for _, s := range scores {
+		v += (s - mean) * (s - mean)
+	}
+	v /= float64(len(scores) - 1)
+	l := math.Pi / math.Sqrt(6*v)
+	u := mean - 0.5772156649015329/l
+	return Karlin{l, math.Exp(l*u) / (float64(m) * float64(n)), math.NaN()}
+}
+
+// 原始分值换算为比特分值
+func (this Karlin) Bits(score float64) float64 {
+	return (this.Lambda*score - math.Log(this.K)) / math.Ln2
+}
+
+// 长度为m的查询序列与总长度为n的数据库比对时，分值不低于score的随机比对的期望个数
+func (this Karlin) EValue(score float64, m, n int) float64 {
+	return this.K * float64(m) * float64(n) * math.Exp(-this.Lambda*score)
+}
+
+// 长度为m的查询序列与总长度为n的数据库比对时，至少出现一个分值不低于score的随机比对的概率
+func (this Karlin) PValue(score float64, m, n int) float64 {
+	return -math.Expm1(-this.EValue(score, m, n))
+}
+
+// 经验p值：将序列二随机打乱n次并用score重新计算分值，返回不低于原始分值的比例（加1修正），seed为随机数种子
+func ShufflePValue(p, q []byte, score func(p, q []byte) float64, n int, seed int64) float64 {
+	if n <= 0 {
+		return math.NaN()
+	}
+	v := score(p, q)
+	r := rand.New(rand.NewSource(seed))
+	t := make([]byte, len(q))
+	copy(t, q)
+	c := 0
+	for i := 0; i < n; i++ {
+		r.Shuffle(len(t), func(i, j int) {
+			t[i], t[j] = t[j], t[i]
+		})
+		if score(p, t) >= v {
+			c++
+		}
+	}
+	return float64(c+1) / float64(n+1)
+}
@@ -0,0 +1,56 @@
+package alignment
+
+import (
+	"math"
+	"testing"
+)
+
+// BLOSUM62与Robinson背景频率下无gap比对的参数，BLAST公布的值为λ=0.3176、K=0.134、H=0.4012
+func TestKarlinAltschul(t *testing.T) {
+	k := KarlinAltschul(BLOSUM62, Robinson, Robinson)
+	if math.Abs(k.Lambda-0.3176) > 0.001 || math.Abs(k.K-0.134) > 0.005 || math.Abs(k.H-0.4012) > 0.005 {
+		t.Errorf("KarlinAltschul(BLOSUM62) = %+v, want λ=0.3176 K=0.134 H=0.4012", k)
+	}
+	// 两种等概率字符、匹配+1错配-2时，e^λ为方程x³-2x²+1=0的根(1+√5)/2
+	f := map[byte]float64{'A': 0.5, 'C': 0.5}
+	m := NewMatrix("1/-2", []byte("AC"), [][]float64{{1, -2}, {-2, 1}})
+	if k := KarlinAltschul(m, f, f); math.Abs(k.Lambda-math.Log(math.Phi)) > 1e-9 {
+		t.Errorf("KarlinAltschul(1/-2).Lambda = %v, want %v", k.Lambda, math.Log(math.Phi))
+	}
+	for name, v := range map[string]float64{"positive expectation": 1, "no positive score": -1} {
+		m := NewMatrix(name, []byte("AC"), [][]float64{{v, v}, {v, v}})
+		k := KarlinAltschul(m, f, f)
+		if !math.IsNaN(k.Lambda) || !math.IsNaN(k.K) || !math.IsNaN(k.H) {
+			t.Errorf("%s: %+v, want NaN", name, k)
+		}
+	}
+}
+
+func TestKarlinValues(t *testing.T) {
+	k := Karlin{Lambda: math.Ln2, K: 0.5}
+	for _, c := range []struct {
+		name      string
+		got, want float64
+	}{
+		// (10ln2-ln0.5)/ln2
+		{"Bits", k.Bits(10), 11},
+		// 0.5×1024×1024×2^-20
+		{"EValue", k.EValue(20, 1024, 1024), 0.5},
+		// 1-exp(-0.5)
+		{"PValue", k.PValue(20, 1024, 1024), 0.3934693402873666},
+	} {
+		if math.Abs(c.got-c.want) > 1e-12 {
+			t.Errorf("%s = %v, want %v", c.name, c.got, c.want)
+		}
+	}
+	if e := EstimateKarlin([]float64{1}, 10, 10); !math.IsNaN(e.Lambda) || !math.IsNaN(e.K) {
+		t.Errorf("EstimateKarlin with one score = %+v, want NaN", e)
+	}
+	if p := ShufflePValue([]byte("ACGT"), []byte("ACGT"), nil, 0, 1); !math.IsNaN(p) {
+		t.Errorf("ShufflePValue with n = 0 is %v, want NaN", p)
+	}
+	same := func(p, q []byte) float64 { return 1 }
+	if p := ShufflePValue([]byte("ACGT"), []byte("ACGT"), same, 9, 1); p != 1 {
+		t.Errorf("ShufflePValue with constant score = %v, want 1", p)
+	}
+}
@@ -0,0 +1,60 @@
+package alignment
+
+// 一条局部比对，Origin和Path的格式与Settle相同
+type Hit struct {
+	Score  float64
+	Origin [2]int
+	Path   []byte
+}
+
+// 使用两个原始序列和字符替换分值函数生成比对结果
+func (this *Hit) Alignment(p, q []byte, f func(byte, byte) float64) *Alignment {
+	return NewAlignment(this.Score, this.Origin, this.Path, p, q, f)
+}
+
+// Waterman-Eggert算法，依次返回最多n个分值大于min的局部比对，参数与SmithWatermanAffine相同；
+// 每找到一条比对就禁止其中对齐的字符对，并重新计算受影响的矩阵区域，因此各比对之间没有相同的对齐字符对，
+// 但同一字符可以出现在不同的比对中（例如序列一中的结构域与序列二中的多个重复结构域分别比对）
+func WatermanEggert(H, W int, mate func(int, int) float64, gap [2]Gap, coef func(int, int) float64, n int, min float64) []Hit {
+	if mate == nil || n <= 0 {
+		return nil
+	}
+	if min < 0 {
+		min = 0
+	}
+	a := newAffine(H, W, mate, gap, coef, true)
+	a.ban = make([][]bool, H)
+	for i := range a.ban {
+		a.ban[i] = make([]bool, W)
+	}
+	m := AffineSW(a.fill(0, 0, H, W, 0))
+	hits := []Hit{}
+	for len(hits) < n {
+		x, y, max := m.top()
+		if max <= min {
+			break
+		}
+		o := m[x][y].ori[0]
+		s := traceback(m, x, y, 0)
+		hits = append(hits, Hit{max, o, s})
+		i, j := o[0], o[1]
+		for _, c := range s {
+			if c == 3 {
+				a.ban[i][j] = true
+			}
+			if c != 2 {
+				i++
+			}
+			if c != 1 {
+				j++
+			}
+		}
+		// 只有起点右下方的方格会受到影响
+		for i := o[0] + 1; i <= H; i++ {
+			for j := o[1] + 1; j <= W; j++ {
+				a.update(m, i, j, i, j)
+			}
+		}
+	}
+	return hits
+}
@@ -0,0 +1,47 @@
+package alignment
+
+import "testing"
+
+func TestWatermanEggert(t *testing.T) {
+	gap := [2]Gap{NewGap(5, 2), NewGap(5, 2)}
+	// 序列二含有GATTACA的一个完整拷贝和一个末位不同的拷贝
+	p, q := "GATTACA", "CCGATTACACCCCGATTACTCC"
+	hits := WatermanEggert(len(p), len(q), MatchString(p, q, unit), gap, nil, 5, 2)
+	want := []Hit{
+		{7, [2]int{0, 2}, []byte{3, 3, 3, 3, 3, 3, 3}},
+		{6, [2]int{0, 13}, []byte{3, 3, 3, 3, 3, 3}},
+	}
+	if len(hits) < len(want) {
+		t.Fatalf("got %d hits, want at least %d", len(hits), len(want))
+	}
+	for k, w := range want {
+		if h := hits[k]; h.Score != w.Score || h.Origin != w.Origin || string(h.Path) != string(w.Path) {
+			t.Errorf("hit %d: got %v, want %v", k, h, w)
+		}
+	}
+	// 分值依次不增，且各比对之间没有相同的对齐字符对
+	used := map[[2]int]bool{}
+	for k, h := range hits {
+		if k > 0 && h.Score > hits[k-1].Score {
+			t.Errorf("hit %d: score %v after %v", k, h.Score, hits[k-1].Score)
+		}
+		if h.Score <= 2 {
+			t.Errorf("hit %d: score %v not above min", k, h.Score)
+		}
+		i, j := h.Origin[0], h.Origin[1]
+		for _, c := range h.Path {
+			if c == 3 {
+				if used[[2]int{i, j}] {
+					t.Errorf("hit %d: pair %d-%d already aligned", k, i, j)
+				}
+				used[[2]int{i, j}] = true
+			}
+			if c != 2 {
+				i++
+			}
+			if c != 1 {
+				j++
+			}
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package cladogram
+
+import "math"
+
+// 检查距离矩阵是否为与名字数目相同的方阵，且距离均为有限值（距离饱和时为+Inf，无法比较时为NaN，会使枝长成为NaN）
+func square(names []string, d [][]float64) bool {
+	if len(names) == 0 || len(d) != len(names) {
+		return false
+	}
+	for i := range d {
+		if len(d[i]) != len(d) {
+			return false
+		}
+		for _, v := range d[i] {
+			if math.IsInf(v, 0) || math.IsNaN(v) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// 复制距离矩阵
+func copyMatrix(d [][]float64) [][]float64 {
+	t := make([][]float64, len(d))
+	for i := range d {
+		t[i] = append([]float64{}, d[i]...)
+	}
+	return t
+}
+
+// 有多对距离相同时，选择下标（按行、列顺序）最小的一对，使结果确定
+func closest(active []bool, d [][]float64) (int, int) {
+	x, y := -1, -1
+	for i := range d {
+		if !active[i] {
+			continue
+		}
+		for j := i + 1; j < len(d); j++ {
+			if active[j] && (x < 0 || d[i][j] < d[x][y]) {
+				x, y = i, j
+			}
+		}
+	}
+	return x, y
+}
+
+// 聚类构建有根的超度量树，weighted为true时新节点到其余节点的距离为两个子节点距离的简单平均（WPGMA），否则按子节点包含的叶数加权平均（UPGMA）
+func cluster(names []string, d [][]float64, weighted bool) *Tree {
+	if !square(names, d) {
+		return nil
+	}
+	n := len(d)
+	d = copyMatrix(d)
+	nodes := make([]Tree, n)
+	height := make([]float64, n)
+	size := make([]float64, n)
+	active := make([]bool, n)
+	for i := range nodes {
+		nodes[i], size[i], active[i] = Tree{Name: names[i]}, 1, true
+	}
+	for k := n; k > 1; k-- {
+		x, y := closest(active, d)
+		h := d[x][y] / 2
+		a, b := nodes[x], nodes[y]
+		a.Value, b.Value = h-height[x], h-height[y]
+		nodes[x], height[x] = Tree{Leaf: []Tree{a, b}}, h
+		for i := range d {
+			if i == x || i == y || !active[i] {
+				continue
+			}
+			v := (d[x][i] + d[y][i]) / 2
+			if !weighted {
+				v = (d[x][i]*size[x] + d[y][i]*size[y]) / (size[x] + size[y])
+			}
+			d[x][i], d[i][x] = v, v
+		}
+		active[y], size[x] = false, size[x]+size[y]
+	}
+	t := nodes[0]
+	return &t
+}
+
+// 用UPGMA方法根据距离矩阵构建有根树，names为各行对应的叶节点名；距离矩阵不是与names等长的方阵或含有+Inf、NaN时返回nil；
+// 多对距离同为最小时先合并下标最小的一对
+func UPGMA(names []string, d [][]float64) *Tree {
+	return cluster(names, d, false)
+}
+
+// 用WPGMA方法根据距离矩阵构建有根树，参数和返回值同UPGMA
+func WPGMA(names []string, d [][]float64) *Tree {
+	return cluster(names, d, true)
+}
+
+// 邻接法构建无根树，以最后剩下的三个节点为根的三个子节点表示；负的枝长被置为0；
+// bionj为true时使用BIONJ的方差加权方法计算新节点的距离
+func join(names []string, d [][]float64, bionj bool) *Tree {
+	if !square(names, d) {
+		return nil
+	}
+	n := len(d)
+	d = copyMatrix(d)
+	v := copyMatrix(d)
+	nodes := make([]Tree, n)
+	active := make([]bool, n)
+	for i := range nodes {
+		nodes[i], active[i] = Tree{Name: names[i]}, true
+	}
+	if n == 1 {
+		return &nodes[0]
+	}
+	if n == 2 {
+		a, b := nodes[0], nodes[1]
+		a.Value, b.Value = positive(d[0][1]/2), positive(d[0][1]/2)
+		return &Tree{Leaf: []Tree{a, b}}
+	}
+	r := make([]float64, n)
+	for k := n; k > 3; k-- {
+		for i := range r {
+			r[i] = 0
+			if !active[i] {
+				continue
+			}
+			for j := range r {
+				if active[j] && j != i {
+					r[i] += d[i][j]
+				}
+			}
+		}
+		// 选择Q值最小的一对
+		x, y, best := -1, -1, float64(0)
+		for i := range d {
+			if !active[i] {
+				continue
+			}
+			for j := i + 1; j < n; j++ {
+				if !active[j] {
+					continue
+				}
+				if q := float64(k-2)*d[i][j] - r[i] - r[j]; x < 0 || q < best {
+					x, y, best = i, j, q
+				}
+			}
+		}
+		lx := d[x][y]/2 + (r[x]-r[y])/float64(2*(k-2))
+		ly := d[x][y] - lx
+		lambda := 0.5
+		if bionj && v[x][y] != 0 {
+			s := float64(0)
+			for i := range d {
+				if active[i] && i != x && i != y {
+					s += v[y][i] - v[x][i]
+				}
+			}
+			lambda = 0.5 + s/(2*float64(k-2)*v[x][y])
+			if lambda < 0 {
+				lambda = 0
+			} else if lambda > 1 {
+				lambda = 1
+			}
+		}
+		a, b := nodes[x], nodes[y]
+		a.Value, b.Value = positive(lx), positive(ly)
+		nodes[x] = Tree{Leaf: []Tree{a, b}}
+		for i := range d {
+			if i == x || i == y || !active[i] {
+				continue
+			}
+			var u float64
+			if bionj {
+				u = lambda*(d[x][i]-lx) + (1-lambda)*(d[y][i]-ly)
+				w := lambda*v[x][i] + (1-lambda)*v[y][i] - lambda*(1-lambda)*v[x][y]
+				v[x][i], v[i][x] = w, w
+			} else {
+				u = (d[x][i] + d[y][i] - d[x][y]) / 2
+			}
+			d[x][i], d[i][x] = u, u
+		}
+		active[y] = false
+	}
+	l := []int{}
+	for i := range active {
+		if active[i] {
+			l = append(l, i)
+		}
+	}
+	a, b, c := l[0], l[1], l[2]
+	root := &Tree{Leaf: []Tree{nodes[a], nodes[b], nodes[c]}}
+	root.Leaf[0].Value = positive((d[a][b] + d[a][c] - d[b][c]) / 2)
+	root.Leaf[1].Value = positive((d[a][b] + d[b][c] - d[a][c]) / 2)
+	root.Leaf[2].Value = positive((d[a][c] + d[b][c] - d[a][b]) / 2)
+	return root
+}
+
+// 负的枝长置为0
+func positive(v float64) float64 {
+	if v < 0 {
+		return 0
+	}
+	return v
+}
+
+// 用邻接法（Saitou & Nei）根据距离矩阵构建无根树，根节点有三个子节点，负的枝长被置为0；
+// 距离矩阵不是与names等长的方阵或含有+Inf、NaN时返回nil；多对的Q值同为最小时先合并下标最小的一对
+func NJ(names []string, d [][]float64) *Tree {
+	return join(names, d, false)
+}
+
+// 用BIONJ方法（Gascuel 1997）根据距离矩阵构建无根树，参数和返回值同NJ
+func BIONJ(names []string, d [][]float64) *Tree {
+	return join(names, d, true)
+}
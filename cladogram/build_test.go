@@ -0,0 +1,103 @@
+package cladogram
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+)
+
+// 以Newick格式（不含结尾的分号）表示树，用于比较
+func newick(t *Tree) string {
+	if len(t.Leaf) == 0 {
+		return fmt.Sprintf("%s:%g", t.Name, t.Value)
+	}
+	s := make([]string, len(t.Leaf))
+	for i := range t.Leaf {
+		s[i] = newick(&t.Leaf[i])
+	}
+	return fmt.Sprintf("(%s):%g", strings.Join(s, ","), t.Value)
+}
+
+func TestBuild(t *testing.T) {
+	names := []string{"a", "b", "c", "d", "e"}
+	// 可加的距离矩阵，真实的树为a 2、b 3、c 4、d 2、e 1，内部枝长3和2
+	additive := [][]float64{
+		{0, 5, 9, 9, 8},
+		{5, 0, 10, 10, 9},
+		{9, 10, 0, 8, 7},
+		{9, 10, 8, 0, 3},
+		{8, 9, 7, 3, 0}}
+	ultrametric := [][]float64{
+		{0, 17, 21, 31, 23},
+		{17, 0, 30, 34, 21},
+		{21, 30, 0, 28, 39},
+		{31, 34, 28, 0, 43},
+		{23, 21, 39, 43, 0}}
+	// 第一步合并a、b时BIONJ的λ为0.625，新节点到c、d的距离与NJ不同
+	quartet := [][]float64{
+		{0, 4, 6, 7},
+		{4, 0, 8, 7},
+		{6, 8, 0, 5},
+		{7, 7, 5, 0}}
+	// 距离全部相同时先合并下标最小的一对
+	ties := [][]float64{
+		{0, 1, 1},
+		{1, 0, 1},
+		{1, 1, 0}}
+	cases := []struct {
+		name  string
+		build func([]string, [][]float64) *Tree
+		names []string
+		d     [][]float64
+		want  string
+	}{
+		{"NJ", NJ, names, additive, "(((a:2,b:3):3,c:4):2,d:2,e:1):0"},
+		{"BIONJ additive", BIONJ, names, additive, "(((a:2,b:3):3,c:4):2,d:2,e:1):0"},
+		{"UPGMA", UPGMA, names, ultrametric, "(((a:8.5,b:8.5):2.5,e:11):5.5,(c:14,d:14):2.5):0"},
+		{"WPGMA", WPGMA, names, ultrametric, "(((a:8.5,b:8.5):2.5,e:11):6.5,(c:14,d:14):3.5):0"},
+		{"NJ quartet", NJ, names[:4], quartet, "((a:1.5,b:2.5):2.5,c:2.5,d:2.5):0"},
+		{"BIONJ quartet", BIONJ, names[:4], quartet, "((a:1.5,b:2.5):2.5,c:2.375,d:2.625):0"},
+		{"UPGMA ties", UPGMA, names[:3], ties, "((a:0.5,b:0.5):0,c:0.5):0"},
+		{"NJ ties", NJ, names[:3], ties, "(a:0.5,b:0.5,c:0.5):0"},
+	}
+	for _, c := range cases {
+		r := c.build(c.names, c.d)
+		if r == nil {
+			t.Errorf("%s: got nil", c.name)
+		} else if s := newick(r); s != c.want {
+			t.Errorf("%s: got %s, want %s", c.name, s, c.want)
+		}
+	}
+}
+
+func TestBuildNotSquare(t *testing.T) {
+	names := []string{"a", "b", "c"}
+	for _, d := range [][][]float64{
+		nil,
+		{{0, 1}, {1, 0}},
+		{{0, 1, 2}, {1, 0}, {2, 1, 0}},
+	} {
+		for name, f := range map[string]func([]string, [][]float64) *Tree{
+			"UPGMA": UPGMA, "WPGMA": WPGMA, "NJ": NJ, "BIONJ": BIONJ,
+		} {
+			if r := f(names, d); r != nil {
+				t.Errorf("%s(%v) = %s, want nil", name, d, newick(r))
+			}
+		}
+	}
+}
+
+func TestBuildNonFinite(t *testing.T) {
+	names := []string{"a", "b", "c"}
+	for _, v := range []float64{math.Inf(1), math.NaN()} {
+		d := [][]float64{{0, 1, v}, {1, 0, 2}, {v, 2, 0}}
+		for name, f := range map[string]func([]string, [][]float64) *Tree{
+			"UPGMA": UPGMA, "WPGMA": WPGMA, "NJ": NJ, "BIONJ": BIONJ,
+		} {
+			if r := f(names, d); r != nil {
+				t.Errorf("%s with distance %v = %v, want nil", name, v, r)
+			}
+		}
+	}
+}
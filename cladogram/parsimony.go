@@ -0,0 +1,301 @@
+package cladogram
+
+import (
+	"fmt"
+	"math"
+
+	. "github.com/hydra13142/bio/sequence"
+)
+
+// 简约法的计分结果
+type Parsimony struct {
+	Length float64            // 树长，即所有位点的最少变化次数（Sankoff为最小代价）之和
+	Sites  []float64          // 各位点的最少变化次数（最小代价）
+	States map[*Tree][][]byte // 各节点在各位点上的最简约状态集合，即在某个最简约重建中该节点可取的状态，按字母表顺序排列
+}
+
+// 状态转换的代价矩阵，Value[i][j]为由Alphabet[i]变为Alphabet[j]的代价
+type Cost struct {
+	Alphabet []byte
+	Value    [][]float64
+}
+
+// 所有状态之间转换代价均为1的代价矩阵，此时Sankoff法与Fitch法等价
+func UnitCost(alphabet []byte) *Cost {
+	c := &Cost{Alphabet: alphabet, Value: make([][]float64, len(alphabet))}
+	for i := range c.Value {
+		c.Value[i] = make([]float64, len(alphabet))
+		for j := range c.Value[i] {
+			if i != j {
+				c.Value[i][j] = 1
+			}
+		}
+	}
+	return c
+}
+
+// 转换代价为ts、颠换代价为tv的核酸代价矩阵，字母表为ACGT
+func TransversionCost(ts, tv float64) *Cost {
+	c := UnitCost([]byte("ACGT"))
+	for i := range c.Value {
+		for j := range c.Value[i] {
+			switch {
+			case i == j:
+			case (i^j)&1 == 0: // A与G、C与T的下标之差为2
+				c.Value[i][j] = ts
+			default:
+				c.Value[i][j] = tv
+			}
+		}
+	}
+	return c
+}
+
+// 按字母表编码后的性状矩阵，每个叶节点在每个位点上为可能状态的位集，缺失数据为全部状态
+type characters struct {
+	alpha  []byte
+	index  [256]int
+	leaves map[string][]uint64
+	sites  int
+}
+
+// 判断一组序列是否为核酸，见Nucleic
+func nucleic(seqs []Sequence) bool {
+	s := make([]*Seq, len(seqs))
+	for i := range seqs {
+		s[i] = &seqs[i].Seq
+	}
+	return Nucleic(s...)
+}
+
+// 将序列编码为性状矩阵；alpha为nil时字母表由序列中出现的字符组成，gap视为一种状态，
+// 核酸的简并碱基表示其对应的多个状态，'?'以及字母表以外的字符视为缺失；序列不等长或状态超过64种时返回错误
+func newCharacters(seqs []Sequence, alpha []byte) (*characters, error) {
+	if len(seqs) == 0 {
+		return nil, fmt.Errorf("Need at least 1 sequence")
+	}
+	dna := nucleic(seqs)
+	norm := func(c byte) byte {
+		if c = Upper(c); c == 'U' && dna {
+			c = 'T'
+		}
+		if c == '.' {
+			c = '-'
+		}
+		return c
+	}
+	ch := &characters{leaves: map[string][]uint64{}, sites: len(seqs[0].Char)}
+	for i := range ch.index {
+		ch.index[i] = -1
+	}
+	add := func(c byte) {
+		if ch.index[c] < 0 {
+			ch.index[c] = len(ch.alpha)
+			ch.alpha = append(ch.alpha, c)
+		}
+	}
+	if alpha != nil {
+		for _, c := range alpha {
+			add(norm(c))
+		}
+	} else {
+		for _, s := range seqs {
+			for _, c := range s.Char {
+				c = norm(c)
+				if b, ok := Degenerate[c]; ok && dna {
+					for k := 0; k < len(b); k++ {
+						add(b[k])
+					}
+				} else if c != '?' && !(c == 'X' && !dna) {
+					add(c)
+				}
+			}
+		}
+	}
+	if len(ch.alpha) > 64 {
+		return nil, fmt.Errorf("Need at most 64 states, got %d", len(ch.alpha))
+	}
+	all := uint64(1)<<uint(len(ch.alpha)) - 1
+	for _, s := range seqs {
+		if len(s.Char) != ch.sites {
+			return nil, fmt.Errorf("Need sequences of equal length, %s has %d rather than %d", s.Name, len(s.Char), ch.sites)
+		}
+		if _, ok := ch.leaves[s.Name]; ok {
+			continue
+		}
+		m := make([]uint64, ch.sites)
+		for j, c := range s.Char {
+			c = norm(c)
+			if k := ch.index[c]; k >= 0 {
+				m[j] = 1 << uint(k)
+				continue
+			}
+			if b, ok := Degenerate[c]; ok && dna {
+				for k := 0; k < len(b); k++ {
+					if x := ch.index[b[k]]; x >= 0 {
+						m[j] |= 1 << uint(x)
+					}
+				}
+			}
+			if m[j] == 0 {
+				m[j] = all
+			}
+		}
+		ch.leaves[s.Name] = m
+	}
+	return ch, nil
+}
+
+// 检查树的所有叶节点都有对应的序列
+func (this *characters) check(t *Tree) error {
+	if len(t.Leaf) == 0 {
+		if _, ok := this.leaves[t.Name]; !ok {
+			return fmt.Errorf("Need sequence of %s", t.Name)
+		}
+		return nil
+	}
+	for i := range t.Leaf {
+		if err := this.check(&t.Leaf[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 用Sankoff方法计算各位点的最小代价以及各节点的最简约状态集合，cost的维数与字母表相同，为nil时为单位代价（即Fitch法）
+func (this *characters) sankoff(t *Tree, cost [][]float64) *Parsimony {
+	k := len(this.alpha)
+	inf := math.Inf(1)
+	p := &Parsimony{Sites: make([]float64, this.sites), States: map[*Tree][][]byte{}}
+	down := map[*Tree][]float64{}
+	var walk func(t *Tree, j int) []float64
+	walk = func(t *Tree, j int) []float64 {
+		v := make([]float64, k)
+		if len(t.Leaf) == 0 {
+			m := this.leaves[t.Name][j]
+			for s := range v {
+				if m&(1<<uint(s)) == 0 {
+					v[s] = inf
+				}
+			}
+		}
+		for i := range t.Leaf {
+			c := transfer(cost, walk(&t.Leaf[i], j), true)
+			for s := range v {
+				v[s] += c[s]
+			}
+		}
+		down[t] = v
+		return v
+	}
+	var up func(t *Tree, u []float64, j int)
+	up = func(t *Tree, u []float64, j int) {
+		d, total := down[t], inf
+		for s := range d {
+			total = math.Min(total, d[s]+u[s])
+		}
+		set := []byte{}
+		for s := range d {
+			if d[s]+u[s] <= total+1e-9 {
+				set = append(set, this.alpha[s])
+			}
+		}
+		p.States[t][j] = set
+		for i := range t.Leaf {
+			c := &t.Leaf[i]
+			// 父节点为s时，除去该子树以外部分的代价
+			r, x := make([]float64, k), transfer(cost, down[c], true)
+			for s := range r {
+				r[s] = inf
+				if !math.IsInf(d[s], 1) {
+					r[s] = u[s] + d[s] - x[s]
+				}
+			}
+			up(c, transfer(cost, r, false), j)
+		}
+	}
+	var alloc func(t *Tree)
+	alloc = func(t *Tree) {
+		p.States[t] = make([][]byte, this.sites)
+		for i := range t.Leaf {
+			alloc(&t.Leaf[i])
+		}
+	}
+	alloc(t)
+	for j := range p.Sites {
+		r := walk(t, j)
+		p.Sites[j] = inf
+		for _, v := range r {
+			p.Sites[j] = math.Min(p.Sites[j], v)
+		}
+		p.Length += p.Sites[j]
+		up(t, make([]float64, k), j)
+	}
+	return p
+}
+
+// 由一个节点各状态的代价c推出相邻节点各状态的最小代价：down为true时c为子节点的代价，结果的第s项为cost[s][x]+c[x]的最小值；
+// 否则c为父节点的代价，结果的第x项为c[s]+cost[s][x]的最小值；cost为nil时为单位代价，结果即min(c[s], min(c)+1)，只需线性时间
+func transfer(cost [][]float64, c []float64, down bool) []float64 {
+	v := make([]float64, len(c))
+	if cost == nil {
+		m := math.Inf(1)
+		for _, x := range c {
+			m = math.Min(m, x)
+		}
+		for s := range v {
+			v[s] = math.Min(c[s], m+1)
+		}
+		return v
+	}
+	for s := range v {
+		v[s] = math.Inf(1)
+		for x := range c {
+			w := cost[s][x]
+			if !down {
+				w = cost[x][s]
+			}
+			v[s] = math.Min(v[s], w+c[x])
+		}
+	}
+	return v
+}
+
+// Fitch简约法：计算树在各位点上的最少变化次数、树长和各节点的最简约状态集合，支持多分叉节点；
+// 叶节点按名字对应到序列，gap视为一种状态，核酸的简并碱基视为其代表的多个状态之一，'?'视为缺失；
+// 有叶节点没有对应的序列或序列不等长时返回错误；按单位代价的Sankoff法计算，结果与Fitch（Hartigan）法相同，
+// 单位代价下每个节点只需线性于状态数的时间，且同一趟计算即可得到各节点的状态集合
+func Fitch(t *Tree, seqs []Sequence) (*Parsimony, error) {
+	ch, err := newCharacters(seqs, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err = ch.check(t); err != nil {
+		return nil, err
+	}
+	return ch.sankoff(t, nil), nil
+}
+
+// Sankoff简约法：按代价矩阵计算树在各位点上的最小代价、树长和各节点的最简约状态集合；
+// 字母表以外的字符视为缺失（核酸的简并碱基视为其代表的多个状态之一），其余同Fitch；代价矩阵维数不正确时返回错误
+func Sankoff(t *Tree, seqs []Sequence, cost *Cost) (*Parsimony, error) {
+	if len(cost.Value) != len(cost.Alphabet) {
+		return nil, fmt.Errorf("Need a %d×%d cost matrix", len(cost.Alphabet), len(cost.Alphabet))
+	}
+	for _, r := range cost.Value {
+		if len(r) != len(cost.Alphabet) {
+			return nil, fmt.Errorf("Need a %d×%d cost matrix", len(cost.Alphabet), len(cost.Alphabet))
+		}
+	}
+	ch, err := newCharacters(seqs, cost.Alphabet)
+	if err != nil {
+		return nil, err
+	}
+	if len(ch.alpha) != len(cost.Alphabet) {
+		return nil, fmt.Errorf("Need distinct letters in the alphabet")
+	}
+	if err = ch.check(t); err != nil {
+		return nil, err
+	}
+	return ch.sankoff(t, cost.Value), nil
+}
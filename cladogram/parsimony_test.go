@@ -0,0 +1,143 @@
+package cladogram
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+
+	. "github.com/hydra13142/bio/sequence"
+)
+
+// 未指定类型的命名序列
+func named(name, s string) Sequence {
+	return Sequence{Name: name, Seq: *NewForwardSeq([]byte(s))}
+}
+
+// 以names为叶节点的随机树，内部节点有2到3个子节点
+func randomTree(r *rand.Rand, names []string) Tree {
+	if len(names) == 1 {
+		return Tree{Name: names[0]}
+	}
+	k := 2 + r.Intn(2)
+	if k > len(names) {
+		k = len(names)
+	}
+	marks := make([]bool, len(names))
+	for _, c := range r.Perm(len(names) - 1)[:k-1] {
+		marks[c+1] = true
+	}
+	t, s := Tree{}, 0
+	for i := 1; i <= len(names); i++ {
+		if i == len(names) || marks[i] {
+			t.Leaf = append(t.Leaf, randomTree(r, names[s:i]))
+			s = i
+		}
+	}
+	return t
+}
+
+func TestFitch(t *testing.T) {
+	tr := Tree{Leaf: []Tree{{Leaf: []Tree{{Name: "a"}, {Name: "b"}}}, {Leaf: []Tree{{Name: "c"}, {Name: "d"}}}}}
+	seqs := []Sequence{named("a", "AAAC-"), named("b", "AACCA"), named("c", "CGAGA"), named("d", "CGTGR")}
+	p, err := Fitch(&tr, seqs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []float64{1, 1, 2, 1, 1}; !reflect.DeepEqual(p.Sites, want) || p.Length != 6 {
+		t.Errorf("Sites = %v, Length = %v, want %v, 6", p.Sites, p.Length, want)
+	}
+	if s := string(p.States[&tr][0]); s != "AC" {
+		t.Errorf("root states at site 0 = %s, want AC", s)
+	}
+	if s := string(p.States[&tr][2]); s != "A" {
+		t.Errorf("root states at site 2 = %s, want A", s)
+	}
+	if _, err = Fitch(&tr, seqs[:3]); err == nil {
+		t.Error("Fitch without sequence of d succeeded")
+	}
+}
+
+func TestSankoffTransversion(t *testing.T) {
+	tr := Tree{Leaf: []Tree{{Leaf: []Tree{{Name: "a"}, {Name: "b"}}}, {Leaf: []Tree{{Name: "c"}, {Name: "d"}}}}}
+	seqs := []Sequence{named("a", "AAA"), named("b", "AAA"), named("c", "GCA"), named("d", "GCA")}
+	f, err := Fitch(&tr, seqs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := Sankoff(&tr, seqs, TransversionCost(1, 2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []float64{1, 1, 0}; !reflect.DeepEqual(f.Sites, want) || f.Length != 2 {
+		t.Errorf("Fitch Sites = %v, Length = %v, want %v, 2", f.Sites, f.Length, want)
+	}
+	if want := []float64{1, 2, 0}; !reflect.DeepEqual(s.Sites, want) || s.Length != 3 {
+		t.Errorf("Sankoff Sites = %v, Length = %v, want %v, 3", s.Sites, s.Length, want)
+	}
+	if r := string(s.States[&tr][0]); r != "AG" {
+		t.Errorf("root states at site 0 = %s, want AG", r)
+	}
+	if _, err = Sankoff(&tr, seqs, &Cost{Alphabet: []byte("ACGT"), Value: [][]float64{{0}}}); err == nil {
+		t.Error("Sankoff with a 1×1 cost matrix for 4 letters succeeded")
+	}
+}
+
+func TestMutation(t *testing.T) {
+	tr := Tree{Leaf: []Tree{{Leaf: []Tree{{Name: "a"}, {Name: "b"}}}, {Leaf: []Tree{{Name: "c"}, {Name: "d"}}}}}
+	seqs := []Sequence{named("a", "AAA"), named("b", "AAA"), named("c", "GCA"), named("d", "GCA")}
+	m, err := Mutation(tr, seqs)
+	if err != nil || !reflect.DeepEqual(m, []int{1, 1, 0}) {
+		t.Errorf("Mutation = %v, %v, want [1 1 0]", m, err)
+	}
+	if _, err = Mutation(tr, seqs[:3]); err == nil {
+		t.Error("Mutation without sequence of d succeeded")
+	}
+}
+
+func TestFitchUnitCost(t *testing.T) {
+	// 单位代价的线性时间计算与一般的Sankoff法结果相同
+	r := rand.New(rand.NewSource(3))
+	names := []string{}
+	for i := 0; i < 12; i++ {
+		names = append(names, fmt.Sprint("t", i))
+	}
+	for it := 0; it < 100; it++ {
+		tr := randomTree(r, names)
+		seqs := []Sequence{}
+		for _, n := range names {
+			b := make([]byte, 20)
+			for j := range b {
+				b[j] = "ACGT-R"[r.Intn(6)]
+			}
+			seqs = append(seqs, named(n, string(b)))
+		}
+		p, err := Fitch(&tr, seqs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		q, err := Sankoff(&tr, seqs, UnitCost([]byte("ACGT-")))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(p.Sites, q.Sites) {
+			t.Fatalf("tree %d: Fitch sites %v, Sankoff sites %v", it, p.Sites, q.Sites)
+		}
+		// 状态集合按各自的字母表顺序排列，比较前统一排序
+		for n, s := range p.States {
+			for j := range s {
+				if a, b := sorted(s[j]), sorted(q.States[n][j]); a != b {
+					t.Fatalf("tree %d site %d: Fitch states %s, Sankoff states %s", it, j, a, b)
+				}
+			}
+		}
+	}
+}
+
+// 排序后的字符串
+func sorted(s []byte) string {
+	b := append([]byte{}, s...)
+	sort.Slice(b, func(i, j int) bool { return b[i] < b[j] })
+	return string(b)
+}
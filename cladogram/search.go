@@ -0,0 +1,486 @@
+package cladogram
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	. "github.com/hydra13142/bio/sequence"
+)
+
+// 树搜索使用的拓扑变换
+type Move int
+
+const (
+	NNI Move = iota // 最近邻交换，交换一条内部枝两端的子树
+	SPR             // 剪切一个子树并嫁接到其余部分的任意一条枝上
+	TBR             // 将树在一条枝处切为两部分，以两部分的任意一条枝重新连接
+)
+
+// 启发式树搜索的参数，每次搜索先按随机顺序逐步加入序列（每步加在使分值最优的枝上）得到起始树，
+// 再反复应用拓扑变换中分值最优的一个，直到无法改进或达到迭代次数
+type Search struct {
+	Move       Move  // 拓扑变换的类型
+	Starts     int   // 随机加入顺序的起始树的个数，不大于0时为1
+	Iterations int   // 每个起始树最多改进的次数，不大于0时不限
+	Seed       int64 // 随机数种子，相同的种子和输入得到相同的结果
+}
+
+// 搜索时使用的无根二叉树，0到n-1为叶节点，n到2n-3为内部节点；构建过程中尚未加入的叶节点没有邻居
+type utree struct {
+	n   int
+	adj [][]int
+}
+
+// 复制树
+func (this *utree) clone() *utree {
+	t := &utree{n: this.n, adj: make([][]int, len(this.adj))}
+	for i := range t.adj {
+		t.adj[i] = append(make([]int, 0, 3), this.adj[i]...)
+	}
+	return t
+}
+
+// 连接两个节点
+func (this *utree) link(a, b int) {
+	this.adj[a] = append(this.adj[a], b)
+	this.adj[b] = append(this.adj[b], a)
+}
+
+// 断开两个节点
+func (this *utree) unlink(a, b int) {
+	remove := func(l []int, x int) []int {
+		for i := range l {
+			if l[i] == x {
+				return append(l[:i], l[i+1:]...)
+			}
+		}
+		return l
+	}
+	this.adj[a] = remove(this.adj[a], b)
+	this.adj[b] = remove(this.adj[b], a)
+}
+
+// 用节点w细分枝e
+func (this *utree) split(w int, e [2]int) {
+	this.unlink(e[0], e[1])
+	this.link(e[0], w)
+	this.link(w, e[1])
+}
+
+// 从节点s出发能到达的所有枝，每条枝只出现一次
+func (this *utree) edges(s int) [][2]int {
+	o := [][2]int{}
+	seen := map[int]bool{s: true}
+	stack := []int{s}
+	for len(stack) > 0 {
+		u := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, v := range this.adj[u] {
+			if !seen[v] {
+				seen[v] = true
+				o = append(o, [2]int{u, v})
+				stack = append(stack, v)
+			}
+		}
+	}
+	return o
+}
+
+// 已加入树中的第一个叶节点
+func (this *utree) first() int {
+	for i := 0; i < this.n; i++ {
+		if len(this.adj[i]) != 0 {
+			return i
+		}
+	}
+	return -1
+}
+
+// 节点u除v以外的邻居
+func (this *utree) others(u, v int) []int {
+	o := make([]int, 0, 2)
+	for _, x := range this.adj[u] {
+		if x != v {
+			o = append(o, x)
+		}
+	}
+	return o
+}
+
+// 所有NNI变换得到的树，每条内部枝两种
+func (this *utree) nni() []*utree {
+	o := []*utree{}
+	for _, e := range this.edges(this.first()) {
+		u, v := e[0], e[1]
+		if u < this.n || v < this.n {
+			continue
+		}
+		a := this.others(u, v)[0]
+		for _, b := range this.others(v, u) {
+			t := this.clone()
+			t.unlink(u, a)
+			t.unlink(v, b)
+			t.link(u, b)
+			t.link(v, a)
+			o = append(o, t)
+		}
+	}
+	return o
+}
+
+// 在枝(a, b)处把树切开后重新连接得到的所有树；a一侧总是重新选择连接的枝，b一侧只有tbr为true时才重新选择，否则仍由b连接
+func (this *utree) reconnect(a, b int, tbr bool) []*utree {
+	base := this.clone()
+	base.unlink(a, b)
+	// 抑制二度的连接点，返回该侧可供连接的枝，连接点为叶节点时返回nil
+	side := func(x int) [][2]int {
+		if x < base.n {
+			return nil
+		}
+		p, q := base.adj[x][0], base.adj[x][1]
+		base.unlink(x, p)
+		base.unlink(x, q)
+		base.link(p, q)
+		return base.edges(p)
+	}
+	ea, eb := side(a), [][2]int(nil)
+	if tbr {
+		eb = side(b)
+	}
+	if ea == nil {
+		ea = [][2]int{{-1, -1}}
+	}
+	if eb == nil {
+		eb = [][2]int{{-1, -1}}
+	}
+	o := []*utree{}
+	for _, x := range ea {
+		for _, y := range eb {
+			t := base.clone()
+			if x[0] >= 0 {
+				t.split(a, x)
+			}
+			if y[0] >= 0 {
+				t.split(b, y)
+			}
+			t.link(a, b)
+			o = append(o, t)
+		}
+	}
+	return o
+}
+
+// 所有SPR（tbr为false）或TBR（tbr为true）变换得到的树
+func (this *utree) rearrange(tbr bool) []*utree {
+	o := []*utree{}
+	for _, e := range this.edges(this.first()) {
+		if tbr {
+			o = append(o, this.reconnect(e[0], e[1], true)...)
+			continue
+		}
+		// 剪下e[1]一侧的子树嫁接到e[0]一侧，再反过来
+		if e[0] >= this.n {
+			o = append(o, this.reconnect(e[0], e[1], false)...)
+		}
+		if e[1] >= this.n {
+			o = append(o, this.reconnect(e[1], e[0], false)...)
+		}
+	}
+	return o
+}
+
+// 启发式搜索，score越小越好；序列数不少于3
+func (this *Search) run(n int, score func(*utree) float64) (*utree, float64) {
+	starts := this.Starts
+	if starts <= 0 {
+		starts = 1
+	}
+	r := rand.New(rand.NewSource(this.Seed))
+	var best *utree
+	bv := math.Inf(1)
+	for s := 0; s < starts; s++ {
+		t, v := this.climb(addition(n, r.Perm(n), score), score)
+		if best == nil || v < bv-1e-9 {
+			best, bv = t, v
+		}
+	}
+	return best, bv
+}
+
+// 按order的顺序逐步加入叶节点，每个叶节点加在使分值最小的枝上
+func addition(n int, order []int, score func(*utree) float64) *utree {
+	t := &utree{n: n, adj: make([][]int, 2*n-2)}
+	for _, x := range order[:3] {
+		t.link(n, x)
+	}
+	for k := 3; k < n; k++ {
+		var best *utree
+		bv := math.Inf(1)
+		for _, e := range t.edges(order[0]) {
+			c := t.clone()
+			c.split(n+k-2, e)
+			c.link(n+k-2, order[k])
+			if v := score(c); best == nil || v < bv-1e-9 {
+				best, bv = c, v
+			}
+		}
+		t = best
+	}
+	return t
+}
+
+// 从t开始爬山，每次应用分值最小的变换，直到无法改进或达到迭代次数
+func (this *Search) climb(t *utree, score func(*utree) float64) (*utree, float64) {
+	v := score(t)
+	for i := 0; this.Iterations <= 0 || i < this.Iterations; i++ {
+		var cand []*utree
+		switch this.Move {
+		case SPR:
+			cand = t.rearrange(false)
+		case TBR:
+			cand = t.rearrange(true)
+		default:
+			cand = t.nni()
+		}
+		next, nv := (*utree)(nil), v
+		for _, c := range cand {
+			if s := score(c); s < nv-1e-9 {
+				next, nv = c, s
+			}
+		}
+		if next == nil {
+			break
+		}
+		t, v = next, nv
+	}
+	return t, v
+}
+
+// 转换为进化树，以叶节点0的邻居为根（有三个子节点），length为各枝的长度，可以为nil
+func (this *utree) tree(names []string, length map[[2]int]float64) *Tree {
+	var conv func(u, p int) Tree
+	conv = func(u, p int) Tree {
+		t := Tree{Value: length[[2]int{p, u}]}
+		if u < this.n {
+			t.Name = names[u]
+			return t
+		}
+		for _, v := range this.adj[u] {
+			if v != p {
+				t.Leaf = append(t.Leaf, conv(v, u))
+			}
+		}
+		return t
+	}
+	root := this.adj[0][0]
+	t := conv(root, -1)
+	t.Value = 0
+	return &t
+}
+
+// 叶节点数少于3时的树
+func small(names []string, d float64) *Tree {
+	if len(names) == 1 {
+		return &Tree{Name: names[0]}
+	}
+	return &Tree{Leaf: []Tree{{Name: names[0], Value: d / 2}, {Name: names[1], Value: d / 2}}}
+}
+
+// 无根二叉树在Fitch简约法下的树长，leaves为各叶节点在各位点上的状态集合
+func fitchLength(t *utree, leaves [][]uint64) float64 {
+	var walk func(u, p int) ([]uint64, int)
+	walk = func(u, p int) ([]uint64, int) {
+		if u < t.n {
+			return leaves[u], 0
+		}
+		c := t.others(u, p)
+		a, x := walk(c[0], u)
+		b, y := walk(c[1], u)
+		s, z := make([]uint64, len(a)), x+y
+		for j := range s {
+			if s[j] = a[j] & b[j]; s[j] == 0 {
+				s[j], z = a[j]|b[j], z+1
+			}
+		}
+		return s, z
+	}
+	r := t.first()
+	s, z := walk(t.adj[r][0], r)
+	for j := range s {
+		if s[j]&leaves[r][j] == 0 {
+			z++
+		}
+	}
+	return float64(z)
+}
+
+// 以Fitch简约法的树长为标准搜索最简约树，叶节点名为序列名；返回树和树长，枝长均为0；
+// 序列名有重复或序列不等长时返回错误
+func (this *Search) Parsimony(seqs []Sequence) (*Tree, float64, error) {
+	ch, err := newCharacters(seqs, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	names := make([]string, len(seqs))
+	leaves := make([][]uint64, len(seqs))
+	for i := range seqs {
+		names[i], leaves[i] = seqs[i].Name, ch.leaves[seqs[i].Name]
+	}
+	if len(ch.leaves) != len(seqs) {
+		return nil, 0, fmt.Errorf("Need distinct sequence names")
+	}
+	if len(seqs) < 3 {
+		t := small(names, 0)
+		p, _ := Fitch(t, seqs)
+		return t, p.Length, nil
+	}
+	t, v := this.run(len(seqs), func(t *utree) float64 {
+		return fitchLength(t, leaves)
+	})
+	return t.tree(names, nil), v, nil
+}
+
+// 一对叶节点之间的路径
+type path struct {
+	i, j  int
+	edges []int
+}
+
+// 各对叶节点之间路径上的枝（按叶节点下标顺序排列），以及所有枝的编号
+func (this *utree) paths() ([]path, map[[2]int]int) {
+	index := map[[2]int]int{}
+	for k, e := range this.edges(this.first()) {
+		index[e], index[[2]int{e[1], e[0]}] = k, k
+	}
+	o := []path{}
+	for i := 0; i < this.n; i++ {
+		if len(this.adj[i]) == 0 {
+			continue
+		}
+		parent := map[int]int{i: -1}
+		queue := []int{i}
+		for len(queue) > 0 {
+			u := queue[0]
+			queue = queue[1:]
+			for _, v := range this.adj[u] {
+				if _, ok := parent[v]; !ok {
+					parent[v] = u
+					queue = append(queue, v)
+				}
+			}
+		}
+		for j := i + 1; j < this.n; j++ {
+			if len(this.adj[j]) == 0 {
+				continue
+			}
+			l := []int{}
+			for u := j; parent[u] >= 0; u = parent[u] {
+				l = append(l, index[[2]int{parent[u], u}])
+			}
+			o = append(o, path{i, j, l})
+		}
+	}
+	return o, index
+}
+
+// 用普通最小二乘法拟合枝长，返回各枝的长度和残差平方和
+func (this *utree) leastSquares(d [][]float64) ([]float64, float64) {
+	paths, index := this.paths()
+	m := len(index) / 2
+	a := make([][]float64, m)
+	for i := range a {
+		a[i] = make([]float64, m+1)
+	}
+	for _, p := range paths {
+		for _, x := range p.edges {
+			for _, y := range p.edges {
+				a[x][y]++
+			}
+			a[x][m] += d[p.i][p.j]
+		}
+	}
+	// 高斯消元解正规方程
+	for k := 0; k < m; k++ {
+		p := k
+		for i := k + 1; i < m; i++ {
+			if math.Abs(a[i][k]) > math.Abs(a[p][k]) {
+				p = i
+			}
+		}
+		a[p], a[k] = a[k], a[p]
+		if a[k][k] == 0 {
+			continue
+		}
+		for i := 0; i < m; i++ {
+			if i != k && a[i][k] != 0 {
+				r := a[i][k] / a[k][k]
+				for j := k; j <= m; j++ {
+					a[i][j] -= r * a[k][j]
+				}
+			}
+		}
+	}
+	x := make([]float64, m)
+	for i := range x {
+		if a[i][i] != 0 {
+			x[i] = a[i][m] / a[i][i]
+		}
+	}
+	s := float64(0)
+	for _, p := range paths {
+		v := d[p.i][p.j]
+		for _, e := range p.edges {
+			v -= x[e]
+		}
+		s += v * v
+	}
+	return x, s
+}
+
+// 转换为带有最小二乘枝长的进化树
+func (this *utree) fitted(names []string, d [][]float64) *Tree {
+	x, _ := this.leastSquares(d)
+	_, index := this.paths()
+	length := make(map[[2]int]float64, len(index))
+	for e, i := range index {
+		length[e] = x[i]
+	}
+	return this.tree(names, length)
+}
+
+// 以最小二乘法拟合的残差平方和为标准搜索最符合距离矩阵的树，返回带有拟合枝长（可能为负）的树和残差平方和；
+// 距离矩阵不是与names等长的方阵或含有+Inf、NaN时返回nil
+func (this *Search) LeastSquares(names []string, d [][]float64) (*Tree, float64) {
+	if !square(names, d) {
+		return nil, math.NaN()
+	}
+	if len(names) < 3 {
+		return small(names, d[0][len(d)-1]), 0
+	}
+	t, v := this.run(len(names), func(t *utree) float64 {
+		_, s := t.leastSquares(d)
+		return s
+	})
+	return t.fitted(names, d), v
+}
+
+// 以平衡最小进化（Pauplin公式计算的树长）为标准搜索树，返回带有最小二乘枝长的树和树长；
+// 距离矩阵不是与names等长的方阵或含有+Inf、NaN时返回nil
+func (this *Search) MinimumEvolution(names []string, d [][]float64) (*Tree, float64) {
+	if !square(names, d) {
+		return nil, math.NaN()
+	}
+	if len(names) < 3 {
+		return small(names, d[0][len(d)-1]), d[0][len(d)-1]
+	}
+	t, v := this.run(len(names), func(t *utree) float64 {
+		s := float64(0)
+		paths, _ := t.paths()
+		for _, p := range paths {
+			s += math.Pow(2, float64(1-len(p.edges))) * d[p.i][p.j]
+		}
+		return s
+	})
+	return t.fitted(names, d), v
+}
@@ -0,0 +1,246 @@
+package cladogram
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	. "github.com/hydra13142/bio/sequence"
+)
+
+// 树中各内部节点（根除外）下的叶节点集合；树以第一个叶节点的邻居为根时即为不含该叶节点一侧的划分
+func splits(t *Tree) []string {
+	o := []string{}
+	var walk func(t *Tree) []string
+	walk = func(t *Tree) []string {
+		if len(t.Leaf) == 0 {
+			return []string{t.Name}
+		}
+		l := []string{}
+		for i := range t.Leaf {
+			l = append(l, walk(&t.Leaf[i])...)
+		}
+		sort.Strings(l)
+		o = append(o, strings.Join(l, ","))
+		return l
+	}
+	walk(t)
+	o = o[:len(o)-1]
+	sort.Strings(o)
+	return o
+}
+
+// 叶节点两两之间的路径长度，返回各叶节点到t的距离
+func leafDistances(t *Tree, d map[[2]string]float64) map[string]float64 {
+	if len(t.Leaf) == 0 {
+		return map[string]float64{t.Name: 0}
+	}
+	all := map[string]float64{}
+	for i := range t.Leaf {
+		sub := leafDistances(&t.Leaf[i], d)
+		for x, u := range sub {
+			u += t.Leaf[i].Value
+			for y, v := range all {
+				d[[2]string{x, y}], d[[2]string{y, x}] = u+v, u+v
+			}
+		}
+		for x, u := range sub {
+			all[x] = u + t.Leaf[i].Value
+		}
+	}
+	return all
+}
+
+// 判断是否为包含全部叶节点的无根二叉树
+func valid(t *utree) bool {
+	for u := range t.adj {
+		k := 3
+		if u < t.n {
+			k = 1
+		}
+		if len(t.adj[u]) != k {
+			return false
+		}
+	}
+	return len(t.edges(0)) == 2*t.n-3
+}
+
+func TestSearchAdditive(t *testing.T) {
+	names := []string{"a", "b", "c", "d", "e"}
+	// 与TestBuild相同的可加距离矩阵，树长17
+	d := [][]float64{
+		{0, 5, 9, 9, 8},
+		{5, 0, 10, 10, 9},
+		{9, 10, 0, 8, 7},
+		{9, 10, 8, 0, 3},
+		{8, 9, 7, 3, 0}}
+	want := []string{"c,d,e", "d,e"}
+	for _, m := range []Move{NNI, SPR, TBR} {
+		s := &Search{Move: m, Starts: 3, Seed: 1}
+		for _, c := range []struct {
+			name  string
+			run   func([]string, [][]float64) (*Tree, float64)
+			score float64
+		}{
+			{"LeastSquares", s.LeastSquares, 0},
+			{"MinimumEvolution", s.MinimumEvolution, 17},
+		} {
+			r, v := c.run(names, d)
+			if r == nil {
+				t.Errorf("%s(%d): got nil", c.name, m)
+				continue
+			}
+			if math.Abs(v-c.score) > 1e-9 {
+				t.Errorf("%s(%d): score = %v, want %v", c.name, m, v, c.score)
+			}
+			if got := splits(r); !reflect.DeepEqual(got, want) {
+				t.Errorf("%s(%d): splits = %v, want %v", c.name, m, got, want)
+			}
+			dist := map[[2]string]float64{}
+			leafDistances(r, dist)
+			for i := range names {
+				for j := i + 1; j < len(names); j++ {
+					if x := dist[[2]string{names[i], names[j]}]; math.Abs(x-d[i][j]) > 1e-9 {
+						t.Errorf("%s(%d): distance %s-%s = %v, want %v", c.name, m, names[i], names[j], x, d[i][j])
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestSearchParsimony(t *testing.T) {
+	// 前4个位点支持(a,b)和(d,e)，各需1步；第5个位点a、d相同，在最简约树上需2步
+	seqs := []Sequence{
+		named("a", "GGAAG"),
+		named("b", "GGAAA"),
+		named("c", "AAACA"),
+		named("d", "AATAG"),
+		named("e", "AATAA")}
+	for _, m := range []Move{NNI, SPR, TBR} {
+		s := &Search{Move: m, Starts: 3, Seed: 1}
+		r, v, err := s.Parsimony(seqs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v != 6 {
+			t.Errorf("Parsimony(%d): length = %v, want 6", m, v)
+		}
+		if got, want := splits(r), []string{"c,d,e", "d,e"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("Parsimony(%d): splits = %v, want %v", m, got, want)
+		}
+		if p, _ := Fitch(r, seqs); p.Length != v {
+			t.Errorf("Parsimony(%d): Fitch length of result = %v, want %v", m, p.Length, v)
+		}
+	}
+	if _, _, err := new(Search).Parsimony([]Sequence{named("a", "AC"), named("a", "AG"), named("b", "AA")}); err == nil {
+		t.Error("Parsimony with duplicate names succeeded")
+	}
+}
+
+func TestSearchSeed(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	names := []string{"a", "b", "c", "d", "e", "f", "g", "h"}
+	seqs := make([]Sequence, len(names))
+	for i := range seqs {
+		b := make([]byte, 30)
+		for j := range b {
+			b[j] = "ACGT"[r.Intn(4)]
+		}
+		seqs[i] = named(names[i], string(b))
+	}
+	d := make([][]float64, len(names))
+	for i := range d {
+		d[i] = make([]float64, len(names))
+		for j := 0; j < i; j++ {
+			d[i][j] = 1 + r.Float64()
+			d[j][i] = d[i][j]
+		}
+	}
+	for _, m := range []Move{NNI, SPR, TBR} {
+		var first [3]string
+		var score [3]float64
+		for k := 0; k < 2; k++ {
+			s := &Search{Move: m, Starts: 4, Seed: 42}
+			p, x, _ := s.Parsimony(seqs)
+			l, y := s.LeastSquares(names, d)
+			e, z := s.MinimumEvolution(names, d)
+			got := [3]string{newick(p), newick(l), newick(e)}
+			if k == 0 {
+				first, score = got, [3]float64{x, y, z}
+			} else if got != first || score != [3]float64{x, y, z} {
+				t.Errorf("move %d: results differ with the same seed: %v %v, then %v %v", m, first, score, got, [3]float64{x, y, z})
+			}
+		}
+	}
+}
+
+func TestSearchMoves(t *testing.T) {
+	const n = 8
+	names := make([]string, n)
+	for i := range names {
+		names[i] = string(rune('a' + i))
+	}
+	key := func(u *utree) string {
+		return strings.Join(splits(u.tree(names, nil)), " ")
+	}
+	r := rand.New(rand.NewSource(1))
+	for k := 0; k < 20; k++ {
+		u := addition(n, r.Perm(n), func(*utree) float64 { return r.Float64() })
+		if !valid(u) {
+			t.Fatalf("addition gave an invalid tree %v", u.adj)
+		}
+		seen := map[string]bool{}
+		for _, c := range []struct {
+			name string
+			o    []*utree
+		}{
+			{"NNI", u.nni()},
+			{"SPR", u.rearrange(false)},
+			{"TBR", u.rearrange(true)},
+		} {
+			// 每条内部枝两种NNI变换，互不相同也不同于原树
+			if c.name == "NNI" && len(c.o) != 2*(n-3) {
+				t.Errorf("NNI gave %d trees, want %d", len(c.o), 2*(n-3))
+			}
+			got := map[string]bool{}
+			for _, x := range c.o {
+				if !valid(x) {
+					t.Errorf("%s gave an invalid tree %v", c.name, x.adj)
+				}
+				got[key(x)] = true
+			}
+			if c.name == "NNI" && (len(got) != len(c.o) || got[key(u)]) {
+				t.Errorf("NNI trees are not distinct from each other and the original")
+			}
+			// NNI的结果包含在SPR中，SPR的结果包含在TBR中
+			for s := range seen {
+				if !got[s] {
+					t.Errorf("%s misses the tree %s", c.name, s)
+				}
+			}
+			seen = got
+		}
+	}
+}
+
+func TestSearchNonFinite(t *testing.T) {
+	names := []string{"a", "b", "c", "d"}
+	for _, v := range []float64{math.Inf(1), math.NaN()} {
+		d := [][]float64{
+			{0, 1, 2, 3},
+			{1, 0, v, 2},
+			{2, v, 0, 1},
+			{3, 2, 1, 0}}
+		s := &Search{Move: NNI, Starts: 1, Seed: 1}
+		if r, _ := s.LeastSquares(names, d); r != nil {
+			t.Errorf("LeastSquares with distance %v = %v, want nil", v, r)
+		}
+		if r, _ := s.MinimumEvolution(names, d); r != nil {
+			t.Errorf("MinimumEvolution with distance %v = %v, want nil", v, r)
+		}
+	}
+}
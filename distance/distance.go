@@ -0,0 +1,294 @@
+// 根据已比对的序列计算两两之间的进化距离，支持核酸的p距离、JC69、K2P、TN93、LogDet和多肽的Poisson、JTT模型
+package distance
+
+import (
+	"math"
+
+	"github.com/hydra13142/bio/sequence"
+)
+
+// 进化距离模型
+type Model int
+
+const (
+	P       Model = iota // 不相同位点的比例，可用于核酸和多肽
+	JC69                 // Jukes-Cantor，核酸
+	K2P                  // Kimura双参数，区分转换和颠换，核酸
+	TN93                 // Tamura-Nei，区分嘌呤转换、嘧啶转换和颠换并考虑碱基组成，核酸
+	LogDet               // LogDet（paralinear），适用于碱基组成不平稳的情况，核酸
+	Poisson              // Poisson校正，多肽
+	JTT                  // JTT替换模型下的最大似然距离，多肽
+)
+
+// 模型的名字
+func (this Model) String() string {
+	switch this {
+	case P:
+		return "p-distance"
+	case JC69:
+		return "JC69"
+	case K2P:
+		return "K2P"
+	case TN93:
+		return "TN93"
+	case LogDet:
+		return "LogDet"
+	case Poisson:
+		return "Poisson"
+	case JTT:
+		return "JTT"
+	}
+	return "Unknown"
+}
+
+// 判断模型是否用于核酸、是否用于多肽
+func (this Model) accept(nucleic bool) bool {
+	switch this {
+	case P:
+		return true
+	case Poisson, JTT:
+		return !nucleic
+	}
+	return nucleic
+}
+
+// 核酸字符的编号，依次为A、C、G、T（U），其余（包括简并碱基和gap）为-1
+var dna [256]int8
+
+// 氨基酸字符的编号，顺序与JTT模型相同，其余（包括B、Z、X、终止符和gap）为-1
+var amino [256]int8
+
+func init() {
+	for i := range dna {
+		dna[i], amino[i] = -1, -1
+	}
+	for i, c := range []byte("ACGT") {
+		dna[c], dna[c+'a'-'A'] = int8(i), int8(i)
+	}
+	dna['U'], dna['u'] = 3, 3
+	for i, c := range []byte(aminoOrder) {
+		amino[c], amino[c+'a'-'A'] = int8(i), int8(i)
+	}
+}
+
+// 字符的编号
+func code(c byte, nucleic bool) int {
+	if nucleic {
+		return int(dna[c])
+	}
+	return int(amino[c])
+}
+
+// 计算两个已比对序列的距离，序列不等长、模型不适用于该类型序列或没有可比较的位点时返回NaN，距离饱和（无法校正）时返回+Inf；
+// 含有gap或简并字符的位点不计入（即成对删除）；cladogram的建树函数不接受+Inf和NaN，需要时可改用P距离或删除相应的序列
+func Pair(p, q *sequence.Seq, model Model) float64 {
+	if len(p.Char) != len(q.Char) {
+		return math.NaN()
+	}
+	n := sequence.Nucleic(p, q)
+	if !model.accept(n) {
+		return math.NaN()
+	}
+	return pair(p.Char, q.Char, model, n, nil, nil)
+}
+
+// 计算一组已比对序列两两之间的距离矩阵；complete为true时删除任一序列含有gap或简并字符的列（完全删除），否则逐对删除；
+// TN93使用全部序列的碱基组成；序列不等长或模型不适用于该类型序列时返回nil，单个距离的含义同Pair（可能为+Inf或NaN）
+func Matrix(seqs []sequence.Sequence, model Model, complete bool) [][]float64 {
+	if len(seqs) == 0 {
+		return nil
+	}
+	s := make([]*sequence.Seq, len(seqs))
+	for i := range seqs {
+		if len(seqs[i].Char) != len(seqs[0].Char) {
+			return nil
+		}
+		s[i] = &seqs[i].Seq
+	}
+	n := sequence.Nucleic(s...)
+	if !model.accept(n) {
+		return nil
+	}
+	var mask []bool
+	if complete {
+		mask = make([]bool, len(seqs[0].Char))
+		for j := range mask {
+			mask[j] = true
+			for i := range seqs {
+				if code(seqs[i].Char[j], n) < 0 {
+					mask[j] = false
+					break
+				}
+			}
+		}
+	}
+	var freq []float64
+	if model == TN93 {
+		freq = make([]float64, 4)
+		for i := range seqs {
+			for j, c := range seqs[i].Char {
+				if k := dna[c]; k >= 0 && (mask == nil || mask[j]) {
+					freq[k]++
+				}
+			}
+		}
+	}
+	d := make([][]float64, len(seqs))
+	for i := range d {
+		d[i] = make([]float64, len(seqs))
+	}
+	for i := range seqs {
+		for j := i + 1; j < len(seqs); j++ {
+			v := pair(seqs[i].Char, seqs[j].Char, model, n, mask, freq)
+			d[i][j], d[j][i] = v, v
+		}
+	}
+	return d
+}
+
+// 统计两个序列中各字符对出现的次数，返回计数矩阵和位点数
+func count(a, b []byte, nucleic bool, mask []bool) ([][]float64, float64) {
+	k := 20
+	if nucleic {
+		k = 4
+	}
+	f := make([][]float64, k)
+	for i := range f {
+		f[i] = make([]float64, k)
+	}
+	n := float64(0)
+	for j := range a {
+		if mask != nil && !mask[j] {
+			continue
+		}
+		x, y := code(a[j], nucleic), code(b[j], nucleic)
+		if x < 0 || y < 0 {
+			continue
+		}
+		f[x][y]++
+		n++
+	}
+	return f, n
+}
+
+// 计算距离，mask为nil时逐对删除，freq为nil时使用这一对序列的碱基组成
+func pair(a, b []byte, model Model, nucleic bool, mask []bool, freq []float64) float64 {
+	f, n := count(a, b, nucleic, mask)
+	if n == 0 {
+		return math.NaN()
+	}
+	diff := n
+	for i := range f {
+		diff -= f[i][i]
+	}
+	p := diff / n
+	switch model {
+	case P:
+		return p
+	case JC69:
+		return correct(-0.75 * math.Log(1-p*4/3))
+	case K2P:
+		// 碱基编号中A、G与C、T的差为2时为转换
+		s := (f[0][2] + f[2][0] + f[1][3] + f[3][1]) / n
+		v := p - s
+		return correct(-0.5*math.Log(1-2*s-v) - 0.25*math.Log(1-2*v))
+	case TN93:
+		return tn93(f, n, freq)
+	case LogDet:
+		return logdet(f, n)
+	case Poisson:
+		return correct(-math.Log(1 - p))
+	case JTT:
+		return jtt(f)
+	}
+	return math.NaN()
+}
+
+// 对数校正失败（参数超出定义域）时距离饱和，返回+Inf；同时避免返回-0
+func correct(d float64) float64 {
+	switch {
+	case math.IsNaN(d):
+		return math.Inf(1)
+	case d == 0:
+		return 0
+	}
+	return d
+}
+
+// Tamura-Nei距离，碱基组成中有缺失的碱基时返回NaN
+func tn93(f [][]float64, n float64, freq []float64) float64 {
+	g := make([]float64, 4)
+	if freq == nil {
+		for i := range f {
+			for j := range f[i] {
+				g[i] += f[i][j]
+				g[j] += f[i][j]
+			}
+		}
+	} else {
+		copy(g, freq)
+	}
+	t := g[0] + g[1] + g[2] + g[3]
+	for i := range g {
+		if g[i] /= t; g[i] == 0 {
+			return math.NaN()
+		}
+	}
+	ga, gc, gg, gt := g[0], g[1], g[2], g[3]
+	gr, gy := ga+gg, gc+gt
+	p1 := (f[0][2] + f[2][0]) / n
+	p2 := (f[1][3] + f[3][1]) / n
+	q := (f[0][1] + f[1][0] + f[0][3] + f[3][0] + f[2][1] + f[1][2] + f[2][3] + f[3][2]) / n
+	d := -2 * ga * gg / gr * math.Log(1-gr/(2*ga*gg)*p1-q/(2*gr))
+	d -= 2 * gc * gt / gy * math.Log(1-gy/(2*gc*gt)*p2-q/(2*gy))
+	d -= 2 * (gr*gy - ga*gg*gy/gr - gc*gt*gr/gy) * math.Log(1-q/(2*gr*gy))
+	return correct(d)
+}
+
+// LogDet（paralinear）距离，行列式不为正时距离饱和
+func logdet(f [][]float64, n float64) float64 {
+	x, y := float64(1), float64(1)
+	m := make([][]float64, 4)
+	for i := range m {
+		m[i] = make([]float64, 4)
+		r, c := float64(0), float64(0)
+		for j := range m[i] {
+			m[i][j] = f[i][j] / n
+			r += f[i][j] / n
+			c += f[j][i] / n
+		}
+		x, y = x*r, y*c
+	}
+	d := det(m)
+	if d <= 0 || x == 0 || y == 0 {
+		return math.Inf(1)
+	}
+	return -0.25 * (math.Log(d) - 0.5*(math.Log(x)+math.Log(y)))
+}
+
+// 用高斯消元计算方阵的行列式，会修改m
+func det(m [][]float64) float64 {
+	d := float64(1)
+	for k := range m {
+		p := k
+		for i := k + 1; i < len(m); i++ {
+			if math.Abs(m[i][k]) > math.Abs(m[p][k]) {
+				p = i
+			}
+		}
+		if m[p][k] == 0 {
+			return 0
+		}
+		if p != k {
+			m[p], m[k], d = m[k], m[p], -d
+		}
+		d *= m[k][k]
+		for i := k + 1; i < len(m); i++ {
+			r := m[i][k] / m[k][k]
+			for j := k; j < len(m); j++ {
+				m[i][j] -= r * m[k][j]
+			}
+		}
+	}
+	return d
+}
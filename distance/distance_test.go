@@ -0,0 +1,120 @@
+package distance
+
+import (
+	"math"
+	"testing"
+
+	"github.com/hydra13142/bio/sequence"
+)
+
+func newDNA(s string) *sequence.Seq {
+	q := sequence.NewForwardSeq([]byte(s))
+	q.AsDNA()
+	return q
+}
+
+func newPeptide(s string) *sequence.Seq {
+	q := sequence.NewForwardSeq([]byte(s))
+	q.AsPipetide()
+	return q
+}
+
+// 40个位点，2个嘌呤转换、2个嘧啶转换、2个颠换，两条序列合计的碱基组成均等
+const (
+	dnaA = "ACGTACGTACGTACGTACGTACGTACGTACGTACGTACGT"
+	dnaB = "GTACCAGTACGTACGTACGTACGTACGTACGTACGTACGT"
+)
+
+// 20个位点，I→V、K→R两处替换
+const (
+	aaA = "ARNDCQEGHILKMFPSTWYV"
+	aaB = "ARNDCQEGHVLRMFPSTWYV"
+)
+
+func TestPair(t *testing.T) {
+	a, b := newDNA(dnaA), newDNA(dnaB)
+	x, y := newPeptide(aaA), newPeptide(aaB)
+	inf, nan := math.Inf(1), math.NaN()
+	for _, c := range []struct {
+		name  string
+		p, q  *sequence.Seq
+		model Model
+		want  float64
+	}{
+		{"P", a, b, P, 0.15},
+		// -0.75ln(1-4×0.15/3)
+		{"JC69", a, b, JC69, 0.16735766348565728},
+		// s=0.1、v=0.05：-0.5ln(1-2s-v)-0.25ln(1-2v)
+		{"K2P", a, b, K2P, 0.17018116514034703},
+		// 碱基组成均等且两类转换比例相同时与K2P相同
+		{"TN93", a, b, TN93, 0.17018116514034703},
+		{"LogDet", a, b, LogDet, 0.17529483806430224},
+		{"JC69(same)", a, a, JC69, 0},
+		// 全部位点不同，无法校正
+		{"JC69(saturated)", newDNA("ACGT"), newDNA("CATG"), JC69, inf},
+		{"LogDet(saturated)", newDNA("ACGT"), newDNA("AAAA"), LogDet, inf},
+		// gap和简并碱基成对删除
+		{"P(gap)", newDNA("AC-TN"), newDNA("ACGAA"), P, 1.0 / 3},
+		{"P(peptide)", x, y, P, 0.1},
+		// -ln(1-0.1)
+		{"Poisson", x, y, Poisson, 0.10536051565782628},
+		// 按JTT速率矩阵的矩阵指数直接求似然的最大值
+		{"JTT", x, y, JTT, 0.10590035772799841},
+		{"JTT(same)", x, x, JTT, 0},
+		{"length", a, newDNA("ACGT"), P, nan},
+		{"JC69(peptide)", x, y, JC69, nan},
+		{"Poisson(DNA)", a, b, Poisson, nan},
+		{"empty", newDNA("--"), newDNA("--"), P, nan},
+	} {
+		got := Pair(c.p, c.q, c.model)
+		switch {
+		case math.IsNaN(c.want):
+			if !math.IsNaN(got) {
+				t.Errorf("%s = %v, want NaN", c.name, got)
+			}
+		case math.IsInf(c.want, 1):
+			if !math.IsInf(got, 1) {
+				t.Errorf("%s = %v, want +Inf", c.name, got)
+			}
+		default:
+			if math.Abs(got-c.want) > 1e-6 {
+				t.Errorf("%s = %v, want %v", c.name, got, c.want)
+			}
+		}
+	}
+}
+
+func TestMatrix(t *testing.T) {
+	seqs := []sequence.Sequence{
+		{Name: "a", Seq: *newDNA("ACGTA")},
+		{Name: "b", Seq: *newDNA("ACGTT")},
+		{Name: "c", Seq: *newDNA("ACG-T")},
+	}
+	// 逐对删除：a-c只比较4个位点
+	d := Matrix(seqs, P, false)
+	want := [][]float64{{0, 0.2, 0.25}, {0.2, 0, 0}, {0.25, 0, 0}}
+	for i := range want {
+		for j := range want[i] {
+			if math.Abs(d[i][j]-want[i][j]) > 1e-12 {
+				t.Errorf("pairwise d[%d][%d] = %v, want %v", i, j, d[i][j], want[i][j])
+			}
+		}
+	}
+	// 完全删除：第4列被删除
+	d = Matrix(seqs, P, true)
+	want = [][]float64{{0, 0.25, 0.25}, {0.25, 0, 0}, {0.25, 0, 0}}
+	for i := range want {
+		for j := range want[i] {
+			if math.Abs(d[i][j]-want[i][j]) > 1e-12 {
+				t.Errorf("complete d[%d][%d] = %v, want %v", i, j, d[i][j], want[i][j])
+			}
+		}
+	}
+	if Matrix(seqs, JTT, false) != nil {
+		t.Error("Matrix with JTT on DNA should be nil")
+	}
+	seqs[2].Seq = *newDNA("ACG")
+	if Matrix(seqs, P, false) != nil {
+		t.Error("Matrix of unequal lengths should be nil")
+	}
+}
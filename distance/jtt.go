@@ -0,0 +1,210 @@
+package distance
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// JTT模型中氨基酸的顺序
+const aminoOrder = "ARNDCQEGHILKMFPSTWYV"
+
+// JTT模型（Jones, Taylor & Thornton 1992）的可交换系数（下三角）和平稳频率，数据来自PAML的jones.dat
+const jttData = `
+ 58
+ 54  45
+ 81  16 528
+ 56 113  34  10
+ 57 310  86  49   9
+105  29  58 767   5 323
+179 137  81 130  59  26 119
+ 27 328 391 112  69 597  26  23
+ 36  22  47  11  17   9  12   6  16
+ 30  38  12   7  23  72   9   6  56 229
+ 35 646 263  26   7 292 181  27  45  21  14
+ 54  44  30  15  31  43  18  14  33 479 388  65
+ 15   5  10   4  78   4   5   5  40  89 248   4  43
+194  74  15  15  14 164  18  24 115  10 102  21  16  17
+378 101 503  59 223  53  30 201  73  40  59  47  29  92 285
+475  64 232  38  42  51  32  33  46 245  25 103 226  12 118 477
+  9 126   8   4 115  18  10  55   8   9  52  10  24  53   6  35  12
+ 11  20  70  46 209  24   7   8 573  32  24   8  18 536  10  63  21  71
+298  17  16  31  62  20  45  47  11 961 180  14 323  62  23  38 112  25  16
+
+0.076748 0.051691 0.042645 0.051544 0.019803 0.040752 0.061830 0.073152
+0.022944 0.053761 0.091904 0.058676 0.023826 0.040126 0.050901 0.068765
+0.058565 0.014261 0.032102 0.066005
+`
+
+// JTT速率矩阵的谱分解：P(t)[a][b] = sqrt(pi[b]/pi[a]) * sum(vec[a][k]*vec[b][k]*exp(val[k]*t))
+var jttModel struct {
+	once sync.Once
+	pi   []float64
+	val  []float64
+	vec  [][]float64
+}
+
+// 解析JTT数据，构建平均速率为1的速率矩阵并对其对称化形式做特征分解
+func loadJTT() {
+	fields := strings.Fields(jttData)
+	num := make([]float64, len(fields))
+	for i, s := range fields {
+		num[i], _ = strconv.ParseFloat(s, 64)
+	}
+	s := make([][]float64, 20)
+	for i := range s {
+		s[i] = make([]float64, 20)
+	}
+	k := 0
+	for i := 1; i < 20; i++ {
+		for j := 0; j < i; j++ {
+			s[i][j], s[j][i] = num[k], num[k]
+			k++
+		}
+	}
+	pi := num[k : k+20]
+	t := float64(0)
+	for _, v := range pi {
+		t += v
+	}
+	for i := range pi {
+		pi[i] /= t
+	}
+	// 平均速率sum(pi[i]*pi[j]*s[i][j])归一化为1
+	r := float64(0)
+	for i := range s {
+		for j := range s {
+			r += pi[i] * pi[j] * s[i][j]
+		}
+	}
+	// 对称化：B = diag(sqrt(pi)) * Q * diag(1/sqrt(pi))
+	b := make([][]float64, 20)
+	for i := range b {
+		b[i] = make([]float64, 20)
+		for j := range b[i] {
+			if i != j {
+				b[i][j] = s[i][j] * math.Sqrt(pi[i]*pi[j]) / r
+				b[i][i] -= s[i][j] * pi[j] / r
+			}
+		}
+	}
+	jttModel.pi = pi
+	jttModel.val, jttModel.vec = jacobi(b)
+}
+
+// 用Jacobi方法求对称矩阵的特征值和特征向量（按列），会修改a
+func jacobi(a [][]float64) ([]float64, [][]float64) {
+	n := len(a)
+	v := make([][]float64, n)
+	for i := range v {
+		v[i] = make([]float64, n)
+		v[i][i] = 1
+	}
+	for sweep := 0; sweep < 100; sweep++ {
+		off := float64(0)
+		for i := 0; i < n; i++ {
+			for j := i + 1; j < n; j++ {
+				off += a[i][j] * a[i][j]
+			}
+		}
+		if off < 1e-30 {
+			break
+		}
+		for p := 0; p < n; p++ {
+			for q := p + 1; q < n; q++ {
+				if a[p][q] == 0 {
+					continue
+				}
+				theta := (a[q][q] - a[p][p]) / (2 * a[p][q])
+				t := 1 / (math.Abs(theta) + math.Sqrt(theta*theta+1))
+				if theta < 0 {
+					t = -t
+				}
+				c := 1 / math.Sqrt(t*t+1)
+				s := t * c
+				for k := 0; k < n; k++ {
+					x, y := a[k][p], a[k][q]
+					a[k][p], a[k][q] = c*x-s*y, s*x+c*y
+				}
+				for k := 0; k < n; k++ {
+					x, y := a[p][k], a[q][k]
+					a[p][k], a[q][k] = c*x-s*y, s*x+c*y
+				}
+				for k := 0; k < n; k++ {
+					x, y := v[k][p], v[k][q]
+					v[k][p], v[k][q] = c*x-s*y, s*x+c*y
+				}
+			}
+		}
+	}
+	val := make([]float64, n)
+	for i := range val {
+		val[i] = a[i][i]
+	}
+	return val, v
+}
+
+// JTT模型下t时刻的转移概率矩阵
+func jttProb(t float64) [][]float64 {
+	m := &jttModel
+	e := make([]float64, 20)
+	for k := range e {
+		e[k] = math.Exp(m.val[k] * t)
+	}
+	p := make([][]float64, 20)
+	for a := range p {
+		p[a] = make([]float64, 20)
+		for b := range p[a] {
+			s := float64(0)
+			for k := range e {
+				s += m.vec[a][k] * m.vec[b][k] * e[k]
+			}
+			p[a][b] = math.Sqrt(m.pi[b]/m.pi[a]) * s
+		}
+	}
+	return p
+}
+
+// 距离的搜索上限，最大似然估计达到上限时视为饱和
+const jttMax = 20
+
+// 用黄金分割搜索计数矩阵f在JTT模型下的最大似然距离
+func jtt(f [][]float64) float64 {
+	jttModel.once.Do(loadJTT)
+	like := func(t float64) float64 {
+		p := jttProb(t)
+		s := float64(0)
+		for a := range f {
+			for b := range f[a] {
+				if f[a][b] != 0 {
+					s += f[a][b] * math.Log(math.Max(p[a][b], 1e-300))
+				}
+			}
+		}
+		return s
+	}
+	g := (math.Sqrt(5) - 1) / 2
+	lo, hi := 0.0, float64(jttMax)
+	x1, x2 := hi-g*(hi-lo), lo+g*(hi-lo)
+	f1, f2 := like(x1), like(x2)
+	for hi-lo > 1e-7 {
+		if f1 < f2 {
+			lo, x1, f1 = x1, x2, f2
+			x2 = lo + g*(hi-lo)
+			f2 = like(x2)
+		} else {
+			hi, x2, f2 = x2, x1, f1
+			x1 = hi - g*(hi-lo)
+			f1 = like(x1)
+		}
+	}
+	t := (lo + hi) / 2
+	if t > jttMax-1e-3 {
+		return math.Inf(1)
+	}
+	if t < 1e-6 {
+		return 0
+	}
+	return t
+}
@@ -0,0 +1,101 @@
+package phy
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// 从PHYLIP格式的距离矩阵文件读取序列名和距离矩阵，支持完整的方阵和下三角矩阵，数值可以跨行；
+// 序列名为每行的第一个字段，不能包含空白字符；第一行只有序列名时为下三角矩阵，否则为方阵（只有一个序列时可以没有数值）
+func Read(r io.Reader) (names []string, d [][]float64, err error) {
+	scan := bufio.NewScanner(r)
+	scan.Buffer(nil, 1<<24)
+	// 当前行中尚未读取的字段
+	var field []string
+	next := func() (string, bool) {
+		for len(field) == 0 {
+			if !scan.Scan() {
+				return "", false
+			}
+			field = strings.Fields(scan.Text())
+		}
+		s := field[0]
+		field = field[1:]
+		return s, true
+	}
+	s, ok := next()
+	if !ok {
+		if err = scan.Err(); err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, fmt.Errorf("Need the number of sequences")
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n <= 0 {
+		return nil, nil, fmt.Errorf("Need a positive number of sequences, got %q", s)
+	}
+	names = make([]string, n)
+	d = make([][]float64, n)
+	for i := range d {
+		d[i] = make([]float64, n)
+	}
+	lower := false
+	for i := 0; i < n; i++ {
+		if names[i], ok = next(); !ok {
+			return nil, nil, fmt.Errorf("Need %d sequences, got %d", n, i)
+		}
+		if i == 0 && len(field) == 0 {
+			lower = true
+		}
+		m := n
+		if lower {
+			m = i
+		}
+		for j := 0; j < m; j++ {
+			if s, ok = next(); !ok {
+				return nil, nil, fmt.Errorf("Need %d distances of %s, got %d", m, names[i], j)
+			}
+			v, e := strconv.ParseFloat(s, 64)
+			if e != nil {
+				return nil, nil, fmt.Errorf("Need a number in distances of %s, got %q", names[i], s)
+			}
+			d[i][j] = v
+			if lower {
+				d[j][i] = v
+			}
+		}
+	}
+	if err = scan.Err(); err != nil {
+		return nil, nil, err
+	}
+	return names, d, nil
+}
+
+// 将距离矩阵以PHYLIP方阵格式写入文件，序列名超过10个字符时被截断
+func Write(w io.Writer, names []string, d [][]float64) error {
+	if len(names) != len(d) {
+		return fmt.Errorf("Need %d names, got %d", len(d), len(names))
+	}
+	for i := range d {
+		if len(d[i]) != len(d) {
+			return fmt.Errorf("Need a square matrix")
+		}
+	}
+	buf := bufio.NewWriter(w)
+	fmt.Fprintf(buf, "    %d\r\n", len(d))
+	for i, name := range names {
+		if len(name) > 10 {
+			fmt.Fprintf(buf, "%s", name[:10])
+		} else {
+			fmt.Fprintf(buf, "%-10s", name)
+		}
+		for _, v := range d[i] {
+			fmt.Fprintf(buf, " %.6f", v)
+		}
+		fmt.Fprintf(buf, "\r\n")
+	}
+	return buf.Flush()
+}
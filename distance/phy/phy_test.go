@@ -0,0 +1,92 @@
+package phy
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var (
+	names = []string{"alpha", "beta", "3", "gamma"}
+	dist  = [][]float64{
+		{0, 0.1, 0.25, 0.5},
+		{0.1, 0, 0.125, 0.375},
+		{0.25, 0.125, 0, 0.75},
+		{0.5, 0.375, 0.75, 0},
+	}
+)
+
+func check(t *testing.T, label string, n []string, d [][]float64, err error) {
+	if err != nil {
+		t.Fatalf("%s: %v", label, err)
+	}
+	if !reflect.DeepEqual(n, names) {
+		t.Errorf("%s: names = %v, want %v", label, n, names)
+	}
+	if !reflect.DeepEqual(d, dist) {
+		t.Errorf("%s: distances = %v, want %v", label, d, dist)
+	}
+}
+
+func TestSquare(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, names, dist); err != nil {
+		t.Fatal(err)
+	}
+	n, d, err := Read(&buf)
+	check(t, "write/read", n, d, err)
+	// 数值跨行，序列名为数字
+	n, d, err = Read(strings.NewReader(`4
+alpha 0 0.1
+  0.25 0.5
+beta 0.1 0 0.125 0.375
+3 0.25 0.125 0 0.75
+gamma 0.5 0.375 0.75 0
+`))
+	check(t, "wrapped", n, d, err)
+}
+
+func TestLower(t *testing.T) {
+	n, d, err := Read(strings.NewReader(`4
+alpha
+beta 0.1
+3 0.25 0.125
+gamma 0.5
+  0.375 0.75
+`))
+	check(t, "lower", n, d, err)
+	var buf bytes.Buffer
+	if err = Write(&buf, n, d); err != nil {
+		t.Fatal(err)
+	}
+	n, d, err = Read(&buf)
+	check(t, "lower/write/read", n, d, err)
+}
+
+func TestSingle(t *testing.T) {
+	for _, s := range []string{"1\nA\n", "1\nA 0\n"} {
+		n, d, err := Read(strings.NewReader(s))
+		if err != nil {
+			t.Errorf("Read(%q): %v", s, err)
+		} else if !reflect.DeepEqual(n, []string{"A"}) || !reflect.DeepEqual(d, [][]float64{{0}}) {
+			t.Errorf("Read(%q) = %v %v", s, n, d)
+		}
+	}
+}
+
+func TestReadError(t *testing.T) {
+	for _, s := range []string{
+		"",
+		"0\n",
+		"x\n",
+		"2\nA\n",
+		"2\nA\nB\n",
+		"2\nA 0 0.1\nB 0.1\n",
+		"2\nA 0 x\nB 0.1 0\n",
+	} {
+		if _, _, err := Read(strings.NewReader(s)); err == nil {
+			t.Errorf("Read(%q) should fail", s)
+		}
+	}
+}
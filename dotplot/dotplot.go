@@ -0,0 +1,257 @@
+// 点阵图（dot plot），用于发现两个序列之间或序列内部的重复、倒位和回文结构，可输出为PNG、SVG或文本
+package dotplot
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+
+	"github.com/hydra13142/bio/sequence"
+)
+
+// 点阵图中的一段连续匹配，X为序列一上的起点，Y为序列二上的起点；
+// Reverse为false时匹配点为(X+t, Y+t)，为true时为序列一与序列二的反向互补链的匹配，匹配点为(X+t, Y-t)，0<=t<Len
+type Segment struct {
+	X, Y, Len int
+	Reverse   bool
+}
+
+// 点阵图，W、H为序列一和序列二的长度
+type Plot struct {
+	W, H     int
+	Segments []Segment
+}
+
+// 两个字符是否匹配，gap和未知字符不匹配
+func same(a, b byte) bool {
+	return a == b && a != '-' && a != 'N' && a != 'X' && a != '?'
+}
+
+// 反向互补序列，不是DNA、RNA时返回nil
+func reverse(q *sequence.Seq) []byte {
+	if k := q.Kind(); k != "DNA" && k != "RNA" {
+		return nil
+	}
+	return sequence.UpperBytes(q.ReverseComplement().Char)
+}
+
+// 用长度为k的完全相同的词生成点阵图，同一对角线上重叠或相邻的词合并为一段；both为true时同时比较序列二的反向互补链
+func Words(p, q *sequence.Seq, k int, both bool) *Plot {
+	a, b := sequence.UpperBytes(p.Char), sequence.UpperBytes(q.Char)
+	plot := &Plot{W: len(a), H: len(b)}
+	if k <= 0 {
+		return plot
+	}
+	plot.Segments = words(a, b, k, false)
+	if r := reverse(q); both && r != nil {
+		plot.Segments = append(plot.Segments, words(a, r, k, true)...)
+	}
+	return plot
+}
+
+// 用词匹配计算一条链上的匹配段，rev为true时b为反向互补链，结果换算到正链坐标
+func words(a, b []byte, k int, rev bool) []Segment {
+	index := map[string][]int{}
+	for j := 0; j+k <= len(b); j++ {
+		index[string(b[j:j+k])] = append(index[string(b[j:j+k])], j)
+	}
+	// 各对角线上最后一段的下标
+	last := map[int]int{}
+	segs := []Segment{}
+	for i := 0; i+k <= len(a); i++ {
+		w := a[i : i+k]
+		if !valid(w) {
+			continue
+		}
+		for _, j := range index[string(w)] {
+			d := j - i
+			if n, ok := last[d]; ok && segs[n].X+segs[n].Len >= i {
+				segs[n].Len = i + k - segs[n].X
+				continue
+			}
+			last[d] = len(segs)
+			segs = append(segs, Segment{i, j, k, false})
+		}
+	}
+	if rev {
+		for n := range segs {
+			segs[n].Y, segs[n].Reverse = len(b)-1-segs[n].Y, true
+		}
+	}
+	return segs
+}
+
+// 词中是否没有gap和未知字符
+func valid(w []byte) bool {
+	for _, c := range w {
+		if !same(c, c) {
+			return false
+		}
+	}
+	return true
+}
+
+// 用滑动窗口生成点阵图，对每条对角线上长度为window的窗口，相同字符的比例不低于identity时标记整个窗口，重叠的窗口合并为一段；
+// both为true时同时比较序列二的反向互补链
+func Window(p, q *sequence.Seq, window int, identity float64, both bool) *Plot {
+	a, b := sequence.UpperBytes(p.Char), sequence.UpperBytes(q.Char)
+	plot := &Plot{W: len(a), H: len(b)}
+	if window <= 0 {
+		return plot
+	}
+	plot.Segments = windows(a, b, window, identity, false)
+	if r := reverse(q); both && r != nil {
+		plot.Segments = append(plot.Segments, windows(a, r, window, identity, true)...)
+	}
+	return plot
+}
+
+// 用滑动窗口计算一条链上的匹配段
+func windows(a, b []byte, window int, identity float64, rev bool) []Segment {
+	need := int(identity*float64(window) + 0.999999)
+	segs := []Segment{}
+	for d := -len(a) + 1; d < len(b); d++ {
+		// 对角线上的点为(i, i+d)
+		i0 := 0
+		if d < 0 {
+			i0 = -d
+		}
+		n := len(a) - i0
+		if m := len(b) - (i0 + d); m < n {
+			n = m
+		}
+		if n < window {
+			continue
+		}
+		cur := -1
+		c := 0
+		for t := 0; t < n; t++ {
+			if same(a[i0+t], b[i0+t+d]) {
+				c++
+			}
+			if t >= window && same(a[i0+t-window], b[i0+t-window+d]) {
+				c--
+			}
+			if t < window-1 || c < need {
+				continue
+			}
+			s := i0 + t - window + 1
+			if cur >= 0 && segs[cur].X+segs[cur].Len >= s {
+				segs[cur].Len = s + window - segs[cur].X
+			} else {
+				cur = len(segs)
+				segs = append(segs, Segment{s, s + d, window, false})
+			}
+		}
+	}
+	if rev {
+		for n := range segs {
+			segs[n].Y, segs[n].Reverse = len(b)-1-segs[n].Y, true
+		}
+	}
+	return segs
+}
+
+// 将每段匹配按比例映射到w×h的格子上，对每个被覆盖的格子调用f
+func (this *Plot) raster(w, h int, f func(x, y int, rev bool)) {
+	if this.W == 0 || this.H == 0 {
+		return
+	}
+	sx, sy := float64(w)/float64(this.W), float64(h)/float64(this.H)
+	for _, s := range this.Segments {
+		// 每个格子至少取样两次
+		step := 1
+		if n := int(float64(s.Len)*sx/2) + 1; s.Len > 2*n {
+			step = s.Len / (2 * n)
+		}
+		for t := 0; t < s.Len; t += step {
+			y := s.Y + t
+			if s.Reverse {
+				y = s.Y - t
+			}
+			f(int(float64(s.X+t)*sx), int(float64(y)*sy), s.Reverse)
+		}
+	}
+}
+
+// 以文本形式输出点阵图，宽cols列、高rows行，序列一为横轴，序列二为纵轴（向下），正向匹配为\，反向匹配为/，两者都有为X；
+// cols或rows不大于0时返回空字符串
+func (this *Plot) ASCII(cols, rows int) string {
+	if cols <= 0 || rows <= 0 {
+		return ""
+	}
+	grid := make([][]byte, rows)
+	for i := range grid {
+		grid[i] = bytes.Repeat([]byte{' '}, cols)
+	}
+	this.raster(cols, rows, func(x, y int, rev bool) {
+		c := byte('\\')
+		if rev {
+			c = '/'
+		}
+		if o := grid[y][x]; o != ' ' && o != c {
+			c = 'X'
+		}
+		grid[y][x] = c
+	})
+	var buf bytes.Buffer
+	border := "+" + string(bytes.Repeat([]byte{'-'}, cols)) + "+\n"
+	buf.WriteString(border)
+	for _, r := range grid {
+		buf.WriteByte('|')
+		buf.Write(r)
+		buf.WriteString("|\n")
+	}
+	buf.WriteString(border)
+	return buf.String()
+}
+
+// 输出width×height像素的PNG图像，白色背景，正向匹配为黑色，反向匹配为红色；width或height不大于0时返回错误
+func (this *Plot) PNG(w io.Writer, width, height int) error {
+	if width <= 0 || height <= 0 {
+		return fmt.Errorf("Need positive image size:%d×%d", width, height)
+	}
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	white := color.RGBA{255, 255, 255, 255}
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.SetRGBA(x, y, white)
+		}
+	}
+	black, red := color.RGBA{0, 0, 0, 255}, color.RGBA{220, 0, 0, 255}
+	this.raster(width, height, func(x, y int, rev bool) {
+		if rev {
+			img.SetRGBA(x, y, red)
+		} else {
+			img.SetRGBA(x, y, black)
+		}
+	})
+	return png.Encode(w, img)
+}
+
+// 输出width×height的SVG图像，每段匹配画为一条线段，正向匹配为黑色，反向匹配为红色；width或height不大于0时返回错误
+func (this *Plot) SVG(w io.Writer, width, height int) error {
+	if width <= 0 || height <= 0 {
+		return fmt.Errorf("Need positive image size:%d×%d", width, height)
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\">\n", width, height, width, height)
+	fmt.Fprintf(&buf, "<rect x=\"0\" y=\"0\" width=\"%d\" height=\"%d\" fill=\"white\" stroke=\"black\"/>\n", width, height)
+	if this.W != 0 && this.H != 0 {
+		sx, sy := float64(width)/float64(this.W), float64(height)/float64(this.H)
+		for _, s := range this.Segments {
+			x1, y1, x2, y2, c := s.X, s.Y, s.X+s.Len, s.Y+s.Len, "black"
+			if s.Reverse {
+				y1, y2, c = s.Y+1, s.Y+1-s.Len, "red"
+			}
+			fmt.Fprintf(&buf, "<line x1=\"%.2f\" y1=\"%.2f\" x2=\"%.2f\" y2=\"%.2f\" stroke=\"%s\"/>\n",
+				float64(x1)*sx, float64(y1)*sy, float64(x2)*sx, float64(y2)*sy, c)
+		}
+	}
+	buf.WriteString("</svg>\n")
+	_, err := w.Write(buf.Bytes())
+	return err
+}
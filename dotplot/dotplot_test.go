@@ -0,0 +1,77 @@
+package dotplot
+
+import (
+	"bytes"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/hydra13142/bio/sequence"
+)
+
+func TestASCII(t *testing.T) {
+	p := sequence.NewForwardSeq([]byte("ACGTTGCAAGGCTTAC"))
+	p.AsDNA()
+	plot := Words(p, p, 4, false)
+	want := "+----+\n|\\   |\n| \\  |\n|  \\ |\n|   \\|\n+----+\n"
+	if s := plot.ASCII(4, 4); s != want {
+		t.Errorf("ASCII(4, 4) =\n%s want\n%s", s, want)
+	}
+	for _, c := range [][2]int{{0, 4}, {4, 0}, {-1, 4}, {4, -1}} {
+		if s := plot.ASCII(c[0], c[1]); s != "" {
+			t.Errorf("ASCII(%d, %d) = %q, want empty", c[0], c[1], s)
+		}
+	}
+}
+
+// 4×4的点阵图，一条正向的主对角线和一条反向的副对角线
+var cross = &Plot{W: 4, H: 4, Segments: []Segment{{0, 0, 4, false}, {0, 3, 4, true}}}
+
+func TestPNG(t *testing.T) {
+	var buf bytes.Buffer
+	if err := cross.PNG(&buf, 4, 4); err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != 4 || b.Dy() != 4 {
+		t.Fatalf("image size %v, want 4×4", b)
+	}
+	white, black, red := color.RGBA{255, 255, 255, 255}, color.RGBA{0, 0, 0, 255}, color.RGBA{220, 0, 0, 255}
+	for _, c := range []struct {
+		x, y int
+		want color.RGBA
+	}{{0, 0, black}, {3, 3, black}, {0, 3, red}, {3, 0, red}, {1, 0, white}, {0, 2, white}} {
+		if got := color.RGBAModel.Convert(img.At(c.x, c.y)); got != c.want {
+			t.Errorf("pixel (%d, %d) = %v, want %v", c.x, c.y, got, c.want)
+		}
+	}
+	for _, c := range [][2]int{{0, 4}, {4, 0}, {-1, 4}} {
+		if err := cross.PNG(&buf, c[0], c[1]); err == nil {
+			t.Errorf("PNG(%d, %d) succeeded", c[0], c[1])
+		}
+	}
+}
+
+func TestSVG(t *testing.T) {
+	var buf bytes.Buffer
+	if err := cross.SVG(&buf, 8, 8); err != nil {
+		t.Fatal(err)
+	}
+	want := `<svg xmlns="http://www.w3.org/2000/svg" width="8" height="8" viewBox="0 0 8 8">
+<rect x="0" y="0" width="8" height="8" fill="white" stroke="black"/>
+<line x1="0.00" y1="0.00" x2="8.00" y2="8.00" stroke="black"/>
+<line x1="0.00" y1="8.00" x2="8.00" y2="0.00" stroke="red"/>
+</svg>
+`
+	if buf.String() != want {
+		t.Errorf("SVG =\n%s want\n%s", buf.String(), want)
+	}
+	for _, c := range [][2]int{{0, 8}, {8, 0}, {8, -1}} {
+		if err := cross.SVG(&buf, c[0], c[1]); err == nil {
+			t.Errorf("SVG(%d, %d) succeeded", c[0], c[1])
+		}
+	}
+}
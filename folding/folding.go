@@ -0,0 +1,448 @@
+// RNA二级结构预测，使用Zuker动态规划算法和Turner 2004最近邻参数计算最小自由能结构，支持两条链的共折叠
+//
+// 为简化起见，参数只包括堆积、发夹环、凸环、内环、多分支环和末端AU/GU罚分，不考虑末端错配、dangle和特殊发夹环；
+// 1×1和1×2内环没有使用Turner的专用参数表（int11、int21），而是用长度为2、3的内环起始能量近似
+package folding
+
+import (
+	"math"
+
+	"github.com/hydra13142/bio/sequence"
+)
+
+// 表示不可能的结构的能量
+const inf = 10000000
+
+// 内环、凸环和发夹环的最大长度，超出的部分按对数外推
+const maxLoop = 30
+
+// 以下能量的单位均为0.01kcal/mol
+var (
+	// 碱基对的堆积能，碱基对的顺序为CG、GC、GU、UG、AU、UA，第二个碱基对从内侧读取
+	stack = [7][7]int{
+		{},
+		{0, -240, -330, -210, -140, -210, -210},
+		{0, -330, -340, -250, -150, -220, -240},
+		{0, -210, -250, 130, -50, -140, -130},
+		{0, -140, -150, -50, 30, -60, -100},
+		{0, -210, -220, -140, -60, -110, -90},
+		{0, -210, -240, -130, -100, -90, -130}}
+
+	// 发夹环的起始能量，下标为环的长度
+	hairpin = [maxLoop + 1]int{inf, inf, inf, 540, 560, 570, 540, 600, 550, 640,
+		650, 660, 670, 678, 686, 694, 701, 707, 713, 719,
+		725, 730, 735, 740, 744, 749, 753, 757, 761, 765, 769}
+
+	// 凸环的起始能量
+	bulge = [maxLoop + 1]int{inf, 380, 280, 320, 360, 400, 440, 459, 470, 480,
+		490, 500, 510, 519, 527, 534, 541, 548, 554, 560,
+		565, 571, 576, 580, 585, 589, 594, 598, 602, 605, 609}
+
+	// 内环的起始能量，长度2、3的值（0.5、1.6）是代替1×1、1×2内环专用参数表的近似值
+	interior = [maxLoop + 1]int{inf, inf, 50, 160, 110, 200, 200, 210, 230, 240,
+		250, 260, 270, 280, 290, 290, 300, 310, 310, 320,
+		330, 330, 340, 340, 350, 350, 350, 360, 360, 370, 370}
+)
+
+const (
+	lxc        = 107.856 // 长环的对数外推系数
+	ninio      = 60      // 内环的不对称罚分，每个碱基
+	ninioMax   = 300     // 不对称罚分的上限
+	terminalAU = 50      // 末端为AU或GU碱基对的罚分
+	closureAU  = 70      // 内环的闭合碱基对为AU或GU时的罚分
+	mlClosing  = 930     // 多分支环的闭合罚分
+	mlIntern   = -90     // 多分支环每个分支的罚分
+	mlBase     = 0       // 多分支环每个未配对碱基的罚分
+	duplexInit = 410     // 两条链形成二聚体的起始能量
+)
+
+// 返回碱基对的类型，1到6依次为CG、GC、GU、UG、AU、UA，不能配对时返回0
+func pairType(a, b byte) int {
+	switch string([]byte{a, b}) {
+	case "CG":
+		return 1
+	case "GC":
+		return 2
+	case "GU":
+		return 3
+	case "UG":
+		return 4
+	case "AU":
+		return 5
+	case "UA":
+		return 6
+	}
+	return 0
+}
+
+// 末端碱基对的罚分
+func terminal(t int) int {
+	if t > 2 {
+		return terminalAU
+	}
+	return 0
+}
+
+// 长度为n的环的能量，超出表格范围的按对数外推
+func extend(table *[maxLoop + 1]int, n int) int {
+	if n <= maxLoop {
+		return table[n]
+	}
+	return table[maxLoop] + int(lxc*math.Log(float64(n)/maxLoop))
+}
+
+// 动态规划的状态
+type folder struct {
+	s   []byte // 序列，共折叠时为两条链首尾相接
+	cut int    // 第二条链的起点，单链时为0
+	n   int
+	v   [][]int // v[i][j]为i、j配对时i到j的最小能量
+	m   [][]int // m[i][j]为多分支环内i到j包含至少一个分支的最小能量
+	m1  [][]int // m1[i][j]为多分支环内i与某个k配对、k之后到j都不配对的最小能量
+	f   [][]int // f[i][j]为i到j作为外部环的最小能量
+}
+
+// 判断断点是否位于i、j之间（第二条链从j开始时也算）
+func (this *folder) split(i, j int) bool {
+	return i < this.cut && this.cut <= j
+}
+
+// 外部环能量，i>j时为空区间
+func (this *folder) ext(i, j int) int {
+	if i > j {
+		return 0
+	}
+	return this.f[i][j]
+}
+
+// i、j闭合的发夹环的能量
+func (this *folder) hairpinE(i, j int) int {
+	n := j - i - 1
+	if n < 3 || this.split(i, j) {
+		return inf
+	}
+	return extend(&hairpin, n) + terminal(pairType(this.s[i], this.s[j]))
+}
+
+// i、j与k、l两个碱基对之间的内环（包括堆积和凸环）的能量
+func (this *folder) interiorE(i, j, k, l int) int {
+	if this.split(i, k) || this.split(l, j) {
+		return inf
+	}
+	t := pairType(this.s[i], this.s[j])
+	r := pairType(this.s[l], this.s[k])
+	a, b := k-i-1, j-l-1
+	switch {
+	case a == 0 && b == 0:
+		return stack[t][r]
+	case a == 0 || b == 0:
+		e := extend(&bulge, a+b)
+		if a+b == 1 {
+			return e + stack[t][r]
+		}
+		return e + terminal(t) + terminal(r)
+	default:
+		e := extend(&interior, a+b)
+		d := ninio * (a - b)
+		if d < 0 {
+			d = -d
+		}
+		if d > ninioMax {
+			d = ninioMax
+		}
+		if t > 2 {
+			e += closureAU
+		}
+		if r > 2 {
+			e += closureAU
+		}
+		return e + d
+	}
+}
+
+// 按区间长度从小到大填充动态规划矩阵
+func (this *folder) fill() {
+	n := this.n
+	this.v, this.m, this.m1, this.f = square(n), square(n), square(n), square(n)
+	for i := 0; i < n; i++ {
+		this.f[i][i] = 0
+	}
+	for d := 1; d < n; d++ {
+		for i := 0; i+d < n; i++ {
+			j := i + d
+			this.v[i][j] = this.paired(i, j, nil)
+			this.m1[i][j] = this.branch(i, j, nil)
+			this.m[i][j] = this.multi(i, j, nil)
+			this.f[i][j] = this.exterior(i, j, nil)
+		}
+	}
+}
+
+// 回溯时使用的任务
+type task struct {
+	kind int // 0为v，1为m，2为m1，3为f
+	i, j int
+}
+
+// 计算v[i][j]；next不为nil时，找到与已填充值相等的分解方式并将其子任务交给next
+func (this *folder) paired(i, j int, next func(...task)) int {
+	t := pairType(this.s[i], this.s[j])
+	if t == 0 || (j-i-1 < 3 && !this.split(i, j)) {
+		return inf
+	}
+	best := this.hairpinE(i, j)
+	if next != nil && best == this.v[i][j] {
+		return best
+	}
+	if this.split(i, j) {
+		e := terminal(t) + this.ext(i+1, this.cut-1) + this.ext(this.cut, j-1)
+		if next != nil && e == this.v[i][j] {
+			next(task{3, i + 1, this.cut - 1}, task{3, this.cut, j - 1})
+			return e
+		}
+		if e < best {
+			best = e
+		}
+	}
+	for k := i + 1; k <= i+maxLoop+1 && k < j-1; k++ {
+		for l := j - 1; l > k && (k-i-1)+(j-l-1) <= maxLoop; l-- {
+			if this.v[k][l] >= inf {
+				continue
+			}
+			e := this.interiorE(i, j, k, l)
+			if e >= inf {
+				continue
+			}
+			e += this.v[k][l]
+			if next != nil && e == this.v[i][j] {
+				next(task{0, k, l})
+				return e
+			}
+			if e < best {
+				best = e
+			}
+		}
+	}
+	if this.cut != i+1 && this.cut != j {
+		for u := i + 2; u < j-1; u++ {
+			if this.cut == u+1 {
+				continue
+			}
+			e := mlClosing + mlIntern + terminal(t) + this.m[i+1][u] + this.m1[u+1][j-1]
+			if next != nil && e == this.v[i][j] {
+				next(task{1, i + 1, u}, task{2, u + 1, j - 1})
+				return e
+			}
+			if e < best {
+				best = e
+			}
+		}
+	}
+	return best
+}
+
+// 计算m1[i][j]
+func (this *folder) branch(i, j int, next func(...task)) int {
+	best := inf
+	if this.v[i][j] < inf {
+		best = this.v[i][j] + mlIntern + terminal(pairType(this.s[i], this.s[j]))
+		if next != nil && best == this.m1[i][j] {
+			next(task{0, i, j})
+			return best
+		}
+	}
+	if j > i && this.cut != j && this.m1[i][j-1] < inf {
+		e := this.m1[i][j-1] + mlBase
+		if next != nil && e == this.m1[i][j] {
+			next(task{2, i, j - 1})
+			return e
+		}
+		if e < best {
+			best = e
+		}
+	}
+	return best
+}
+
+// 计算m[i][j]
+func (this *folder) multi(i, j int, next func(...task)) int {
+	best := inf
+	for u := i; u < j; u++ {
+		if this.m1[u][j] >= inf {
+			continue
+		}
+		if !this.split(i, u) {
+			e := this.m1[u][j] + mlBase*(u-i)
+			if next != nil && e == this.m[i][j] {
+				next(task{2, u, j})
+				return e
+			}
+			if e < best {
+				best = e
+			}
+		}
+		if u > i+1 && this.cut != u && this.m[i][u-1] < inf {
+			e := this.m[i][u-1] + this.m1[u][j]
+			if next != nil && e == this.m[i][j] {
+				next(task{1, i, u - 1}, task{2, u, j})
+				return e
+			}
+			if e < best {
+				best = e
+			}
+		}
+	}
+	return best
+}
+
+// 计算f[i][j]
+func (this *folder) exterior(i, j int, next func(...task)) int {
+	best := this.ext(i, j-1)
+	if next != nil && best == this.f[i][j] {
+		next(task{3, i, j - 1})
+		return best
+	}
+	for k := i; k < j; k++ {
+		if this.v[k][j] >= inf {
+			continue
+		}
+		e := this.ext(i, k-1) + this.v[k][j] + terminal(pairType(this.s[k], this.s[j]))
+		if next != nil && e == this.f[i][j] {
+			next(task{3, i, k - 1}, task{0, k, j})
+			return e
+		}
+		if e < best {
+			best = e
+		}
+	}
+	return best
+}
+
+// 从外部环开始回溯，返回每个碱基的配对对象，不配对为-1
+func (this *folder) trace() []int {
+	p := make([]int, this.n)
+	for i := range p {
+		p[i] = -1
+	}
+	list := []task{{3, 0, this.n - 1}}
+	next := func(t ...task) {
+		list = append(list, t...)
+	}
+	for len(list) > 0 {
+		t := list[len(list)-1]
+		list = list[:len(list)-1]
+		if t.i >= t.j {
+			continue
+		}
+		switch t.kind {
+		case 0:
+			p[t.i], p[t.j] = t.j, t.i
+			this.paired(t.i, t.j, next)
+		case 1:
+			this.multi(t.i, t.j, next)
+		case 2:
+			this.branch(t.i, t.j, next)
+		case 3:
+			this.exterior(t.i, t.j, next)
+		}
+	}
+	return p
+}
+
+// 生成n×n的矩阵，所有元素初始化为inf
+func square(n int) [][]int {
+	t := make([][]int, n)
+	for i := range t {
+		t[i] = make([]int, n)
+		for j := range t[i] {
+			t[i][j] = inf
+		}
+	}
+	return t
+}
+
+// 将序列整理为5'到3'方向的RNA碱基，DNA的T视为U；非DNA、RNA序列返回nil
+func prepare(s *sequence.Seq) []byte {
+	switch s.Kind() {
+	case "DNA", "RNA":
+	default:
+		return nil
+	}
+	if s.Direction() == "3 => 5" {
+		s = s.Reverse()
+	}
+	c := s.DeleteGaps().Char
+	for i, b := range c {
+		if b == 'T' {
+			c[i] = 'U'
+		}
+	}
+	return c
+}
+
+// 将配对信息表示为点括号形式，cut大于0时在该位置插入'&'
+func bracket(p []int, cut int) string {
+	b := make([]byte, 0, len(p)+1)
+	for i, j := range p {
+		if cut > 0 && i == cut {
+			b = append(b, '&')
+		}
+		switch {
+		case j < 0:
+			b = append(b, '.')
+		case j > i:
+			b = append(b, '(')
+		default:
+			b = append(b, ')')
+		}
+	}
+	return string(b)
+}
+
+// 对序列s和断点cut进行折叠，返回点括号结构和最小自由能（kcal/mol）
+func fold(s []byte, cut int) (string, float64) {
+	if len(s) == 0 {
+		return "", 0
+	}
+	this := &folder{s: s, cut: cut, n: len(s)}
+	this.fill()
+	e := this.ext(0, this.n-1)
+	p := this.trace()
+	if cut <= 0 {
+		return bracket(p, 0), float64(e) / 100
+	}
+	// 只有存在跨越两条链的碱基对时才计入二聚体的起始能量，此时再与两条链各自折叠的结果比较
+	for i, j := range p {
+		if i < cut && j >= cut {
+			a, x := fold(s[:cut], 0)
+			b, y := fold(s[cut:], 0)
+			if x+y <= float64(e+duplexInit)/100 {
+				return a + "&" + b, x + y
+			}
+			return bracket(p, cut), float64(e+duplexInit) / 100
+		}
+	}
+	return bracket(p, cut), float64(e) / 100
+}
+
+// 预测单链的最小自由能二级结构，返回点括号表示和自由能（kcal/mol）；DNA使用RNA的参数近似计算；非DNA、RNA序列返回空字符串和NaN
+func Fold(s *sequence.Seq) (string, float64) {
+	c := prepare(s)
+	if c == nil {
+		return "", math.NaN()
+	}
+	return fold(c, 0)
+}
+
+// 预测两条链形成的二聚体的最小自由能结构，点括号表示中两条链以'&'分隔；有跨越两条链的碱基对时自由能包括二聚体的起始能量，
+// 否则即为两条链各自折叠的自由能之和；非DNA、RNA序列返回空字符串和NaN
+func Cofold(a, b *sequence.Seq) (string, float64) {
+	p, q := prepare(a), prepare(b)
+	if p == nil || q == nil {
+		return "", math.NaN()
+	}
+	if len(p) == 0 || len(q) == 0 {
+		return fold(append(p, q...), 0)
+	}
+	return fold(append(append([]byte(nil), p...), q...), len(p))
+}
@@ -0,0 +1,45 @@
+package folding
+
+import (
+	"math"
+	"testing"
+
+	"github.com/hydra13142/bio/sequence"
+)
+
+func rna(s string) *sequence.Seq {
+	r := sequence.NewForwardSeq([]byte(s))
+	r.AsRNA()
+	return r
+}
+
+func TestCofold(t *testing.T) {
+	// 不能配对的两条链不计入二聚体的起始能量
+	st, e := Cofold(rna("AAAAAAAA"), rna("AAAAAAAA"))
+	if st != "........&........" || e != 0 {
+		t.Errorf("unpaired strands: %s %.2f", st, e)
+	}
+	// 互补的两条链形成二聚体，自由能包括起始能量
+	st, e = Cofold(rna("GGGGCGCGCC"), rna("GGCGCGCCCC"))
+	// 堆积GG/CC 4×-3.3、GC/CG 3×-3.4、CG/GC 2×-2.4，加上起始能量4.1
+	if st != "((((((((((&))))))))))" || math.Abs(e+24.1) > 1e-9 {
+		t.Errorf("duplex: %s %.2f, want ((((((((((&)))))))))) -24.10", st, e)
+	}
+	// 自身互补的GGAUCC：五个堆积-3.3-2.4-1.1-2.4-3.3加上二聚体起始能量4.1
+	st, e = Cofold(rna("GGAUCC"), rna("GGAUCC"))
+	if st != "((((((&))))))" || math.Abs(e+8.4) > 1e-9 {
+		t.Errorf("GGAUCC dimer: %s %.2f, want ((((((&)))))) -8.40", st, e)
+	}
+}
+
+func TestFold(t *testing.T) {
+	// 三个GG/CC堆积（各-3.3）加上3个碱基的发夹环（5.4）
+	st, e := Fold(rna("GGGGAAACCCC"))
+	if st != "((((...))))" || math.Abs(e+4.5) > 1e-9 {
+		t.Errorf("hairpin: %s %.2f, want ((((...)))) -4.50", st, e)
+	}
+	st, e = Fold(rna("AAAAAAAA"))
+	if st != "........" || e != 0 {
+		t.Errorf("unstructured: %s %.2f", st, e)
+	}
+}
@@ -0,0 +1,239 @@
+package msa
+
+import (
+	"math"
+	"sort"
+
+	"github.com/hydra13142/bio/sequence"
+)
+
+// 多序列比对的结果，即一组等长的带gap序列，可直接由aln.Read、phy.Read的结果转换而来
+type Alignment []sequence.Sequence
+
+// 判断是否为gap字符
+func isGap(c byte) bool {
+	return c == '-' || c == '.'
+}
+
+// 比对的列数，序列不等长或没有序列时返回-1
+func (this Alignment) Len() int {
+	if len(this) == 0 {
+		return -1
+	}
+	for _, s := range this[1:] {
+		if len(s.Char) != len(this[0].Char) {
+			return -1
+		}
+	}
+	return len(this[0].Char)
+}
+
+// 判断所有序列是否等长
+func (this Alignment) Valid() bool {
+	return this.Len() >= 0
+}
+
+// 返回只保留mask中为true的列的新比对，mask的长度必须与比对的列数相同，否则返回nil
+func (this Alignment) Select(mask []bool) Alignment {
+	if this.Len() != len(mask) {
+		return nil
+	}
+	ans := make(Alignment, len(this))
+	for i, s := range this {
+		t := make([]byte, 0, len(mask))
+		for j, ok := range mask {
+			if ok {
+				t = append(t, s.Char[j])
+			}
+		}
+		ans[i] = sequence.Sequence{Name: s.Name, Seq: s.Seq}
+		ans[i].Char = t
+	}
+	return ans
+}
+
+// 返回第from到第to-1列组成的新比对，to不大于0时加上列数；范围无效时返回nil
+func (this Alignment) Trim(from, to int) Alignment {
+	l := this.Len()
+	if to <= 0 {
+		to += l
+	}
+	if l < 0 || from < 0 || to > l || from > to {
+		return nil
+	}
+	mask := make([]bool, l)
+	for j := from; j < to; j++ {
+		mask[j] = true
+	}
+	return this.Select(mask)
+}
+
+// 各列中gap所占的比例
+func (this Alignment) GapFraction() []float64 {
+	l := this.Len()
+	if l < 0 {
+		return nil
+	}
+	f := make([]float64, l)
+	for _, s := range this {
+		for j, c := range s.Char {
+			if isGap(c) {
+				f[j]++
+			}
+		}
+	}
+	for j := range f {
+		f[j] /= float64(len(this))
+	}
+	return f
+}
+
+// 删除gap比例不低于fraction的列，fraction为1时只删除全部为gap的列
+func (this Alignment) RemoveGaps(fraction float64) Alignment {
+	f := this.GapFraction()
+	if f == nil {
+		return nil
+	}
+	mask := make([]bool, len(f))
+	for j, v := range f {
+		mask[j] = v < fraction
+	}
+	return this.Select(mask)
+}
+
+// 统计第j列各字符（大写）的计数，简并碱基平均分配到其代表的各碱基上，gap不计入
+func (this Alignment) count(j int, nucleic bool) map[byte]float64 {
+	m := map[byte]float64{}
+	for _, s := range this {
+		c := sequence.Upper(s.Char[j])
+		if isGap(c) {
+			continue
+		}
+		if c == 'U' && nucleic {
+			c = 'T'
+		}
+		if b, ok := sequence.Degenerate[c]; ok && nucleic {
+			for i := 0; i < len(b); i++ {
+				m[b[i]] += 1 / float64(len(b))
+			}
+			continue
+		}
+		m[c]++
+	}
+	return m
+}
+
+// 碱基集合对应的简并碱基，由sequence.Degenerate反查得到
+var iupac = map[string]byte{}
+
+func init() {
+	for _, c := range []byte("ACGTRYSWKMBDHVN") {
+		iupac[sequence.Degenerate[c]] = c
+	}
+}
+
+// 计算一致序列：gap比例超过一半的列为gap；其余列中，核酸按频率从高到低取碱基，直到累计频率不低于threshold，
+// 用这些碱基对应的简并碱基表示（iupac为false时只取最多的碱基，不足threshold时为N）；多肽取最多的残基，频率低于threshold时为X；
+// 频率以非gap字符为分母
+func (this Alignment) Consensus(threshold float64, iupac bool) []byte {
+	f := this.GapFraction()
+	if f == nil {
+		return nil
+	}
+	nucleic := !peptide(this)
+	t := make([]byte, len(f))
+	for j := range t {
+		if f[j] > 0.5 {
+			t[j] = '-'
+			continue
+		}
+		m := this.count(j, nucleic)
+		t[j] = consensus(m, threshold, nucleic, iupac)
+	}
+	return t
+}
+
+// 计算一列的一致字符
+func consensus(m map[byte]float64, threshold float64, nucleic, ambig bool) byte {
+	type kv struct {
+		c byte
+		n float64
+	}
+	l, n := []kv{}, float64(0)
+	for c, v := range m {
+		l = append(l, kv{c, v})
+		n += v
+	}
+	sort.Slice(l, func(i, j int) bool {
+		if l[i].n != l[j].n {
+			return l[i].n > l[j].n
+		}
+		return l[i].c < l[j].c
+	})
+	unknown := byte('X')
+	if nucleic {
+		unknown = 'N'
+	}
+	if len(l) == 0 {
+		return unknown
+	}
+	if !nucleic || !ambig {
+		if l[0].n/n >= threshold {
+			return l[0].c
+		}
+		return unknown
+	}
+	set, sum := []byte{}, float64(0)
+	for _, x := range l {
+		set, sum = append(set, x.c), sum+x.n
+		if sum/n >= threshold {
+			break
+		}
+	}
+	sort.Slice(set, func(i, j int) bool { return set[i] < set[j] })
+	if c, ok := iupac[string(set)]; ok {
+		return c
+	}
+	return unknown
+}
+
+// 各列的保守度，即最多的字符占全部序列（包括gap）的比例
+func (this Alignment) Conservation() []float64 {
+	l := this.Len()
+	if l < 0 {
+		return nil
+	}
+	nucleic := !peptide(this)
+	o := make([]float64, l)
+	for j := range o {
+		for _, v := range this.count(j, nucleic) {
+			if v > o[j] {
+				o[j] = v
+			}
+		}
+		o[j] /= float64(len(this))
+	}
+	return o
+}
+
+// 各列非gap字符的香农熵（比特），熵越低越保守；全部为gap的列为0
+func (this Alignment) Entropy() []float64 {
+	l := this.Len()
+	if l < 0 {
+		return nil
+	}
+	nucleic := !peptide(this)
+	o := make([]float64, l)
+	for j := range o {
+		m := this.count(j, nucleic)
+		n := float64(0)
+		for _, v := range m {
+			n += v
+		}
+		for _, v := range m {
+			p := v / n
+			o[j] -= p * math.Log2(p)
+		}
+	}
+	return o
+}
@@ -0,0 +1,106 @@
+package msa
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func aligned(s ...string) Alignment {
+	a := make(Alignment, len(s))
+	for i, c := range s {
+		a[i] = named(string(rune('a'+i)), c)
+	}
+	return a
+}
+
+func rows(a Alignment) []string {
+	r := make([]string, len(a))
+	for i, s := range a {
+		r[i] = string(s.Char)
+	}
+	return r
+}
+
+func near(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if math.Abs(a[i]-b[i]) > 1e-9 {
+			return false
+		}
+	}
+	return true
+}
+
+func TestConsensus(t *testing.T) {
+	a := aligned("ACGT-A", "ACGA-A", "ATGC-C", "ACG--G")
+	for _, c := range []struct {
+		threshold float64
+		iupac     bool
+		want      string
+	}{
+		// 第4列A、C、T各1/3，第6列A为1/2、C和G各1/4
+		{0.7, true, "ACGH-M"},
+		{0.7, false, "ACGN-N"},
+		{0.9, true, "AYGH-V"},
+	} {
+		if s := string(a.Consensus(c.threshold, c.iupac)); s != c.want {
+			t.Errorf("Consensus(%v, %v) = %s, want %s", c.threshold, c.iupac, s, c.want)
+		}
+	}
+	// 简并碱基平均分配到其代表的碱基上，X与N相同，不区分大小写
+	// A为(0.5+1+0.25+1)/4，G为(0.5+0.25)/4
+	r := aligned("r", "A", "X", "A")
+	if s := string(r.Consensus(0.6, true)) + string(r.Consensus(0.8, true)); s != "AR" {
+		t.Errorf("Consensus of r, A, X, A at 0.6 and 0.8 = %s, want AR", s)
+	}
+	// 含有I的一组序列为多肽
+	p := aligned("MKV", "MRV", "MKI")
+	if s := string(p.Consensus(0.6, false)); s != "MKV" {
+		t.Errorf("peptide Consensus(0.6) = %s, want MKV", s)
+	}
+	if s := string(p.Consensus(0.9, true)); s != "MXX" {
+		t.Errorf("peptide Consensus(0.9) = %s, want MXX", s)
+	}
+	if aligned("ACG", "AC").Consensus(0.5, true) != nil {
+		t.Error("Consensus of sequences of different lengths != nil")
+	}
+}
+
+func TestColumnStatistics(t *testing.T) {
+	a := aligned("ACGT-A", "ACGA-A", "ATGC-C", "ACG--G")
+	if f := a.GapFraction(); !near(f, []float64{0, 0, 0, 0.25, 1, 0}) {
+		t.Errorf("GapFraction = %v", f)
+	}
+	if c := a.Conservation(); !near(c, []float64{1, 0.75, 1, 0.25, 0, 0.5}) {
+		t.Errorf("Conservation = %v", c)
+	}
+	if e := a.Entropy(); !near(e, []float64{0, 0.8112781244591328, 0, math.Log2(3), 0, 1.5}) {
+		t.Errorf("Entropy = %v", e)
+	}
+	if c := aligned("r", "A", "X", "A").Conservation(); !near(c, []float64{0.6875}) {
+		t.Errorf("Conservation of r, A, X, A = %v, want [0.6875]", c)
+	}
+	for _, c := range []struct {
+		name string
+		got  Alignment
+		want []string
+	}{
+		{"RemoveGaps(1)", a.RemoveGaps(1), []string{"ACGTA", "ACGAA", "ATGCC", "ACG-G"}},
+		{"RemoveGaps(0.25)", a.RemoveGaps(0.25), []string{"ACGA", "ACGA", "ATGC", "ACGG"}},
+		{"Trim(1, -1)", a.Trim(1, -1), []string{"CGT-", "CGA-", "TGC-", "CG--"}},
+		{"Select", a.Select([]bool{true, false, false, false, false, true}), []string{"AA", "AA", "AC", "AG"}},
+	} {
+		if r := rows(c.got); !reflect.DeepEqual(r, c.want) {
+			t.Errorf("%s = %v, want %v", c.name, r, c.want)
+		}
+	}
+	if a.Trim(3, 2) != nil || a.Select([]bool{true}) != nil {
+		t.Error("invalid Trim or Select != nil")
+	}
+	if b := aligned("ACG", "AC"); b.Len() != -1 || b.Valid() || b.GapFraction() != nil {
+		t.Error("alignment of sequences of different lengths is valid")
+	}
+}
@@ -0,0 +1,298 @@
+// 渐进式多序列比对：先用两两比对的距离构建指导树，再按指导树从叶到根逐步进行谱（profile）与谱的比对
+package msa
+
+import (
+	"context"
+
+	"github.com/hydra13142/bio/alignment"
+	"github.com/hydra13142/bio/cladogram"
+	"github.com/hydra13142/bio/sequence"
+)
+
+// 多序列比对的参数
+type Config struct {
+	Matrix *alignment.Matrix // 替换矩阵，为nil时多肽使用BLOSUM62，其余使用NUC44
+	Gap    alignment.Gap     // 两个序列（谱）中添加空位的罚分
+}
+
+// 默认的比对参数，两端的gap罚分较低
+var DefaultConfig = Config{Gap: alignment.Gap{Open: 10, Extend: 1, EndOpen: 1, EndExtend: 1}}
+
+// 谱（profile），即已经比对好的一组等长的带gap序列
+type Profile struct {
+	Seqs  []sequence.Sequence
+	index []int // 各序列在渐进比对输入中的下标
+	cols  [][]residue
+}
+
+// 谱的一列中某种字符的计数
+type residue struct {
+	char byte
+	n    float64
+}
+
+// 用已比对的序列创建谱，序列不等长时返回nil；序列数据会被拷贝
+func NewProfile(seqs []sequence.Sequence) *Profile {
+	if len(seqs) == 0 {
+		return nil
+	}
+	t := make([]sequence.Sequence, len(seqs))
+	index := make([]int, len(seqs))
+	for i := range seqs {
+		if len(seqs[i].Char) != len(seqs[0].Char) {
+			return nil
+		}
+		t[i] = sequence.Sequence{Name: seqs[i].Name, Seq: seqs[i].Seq}
+		t[i].Char = append([]byte{}, seqs[i].Char...)
+		index[i] = i
+	}
+	return newProfile(index, t)
+}
+
+// 创建谱并统计各列的字符组成
+func newProfile(index []int, seqs []sequence.Sequence) *Profile {
+	p := &Profile{Seqs: seqs, index: index}
+	p.cols = make([][]residue, len(seqs[0].Char))
+	for j := range p.cols {
+	next:
+		for _, r := range seqs {
+			c := sequence.Upper(r.Char[j])
+			if c == '-' || c == '.' {
+				continue
+			}
+			for k := range p.cols[j] {
+				if p.cols[j][k].char == c {
+					p.cols[j][k].n++
+					continue next
+				}
+			}
+			p.cols[j] = append(p.cols[j], residue{c, 1})
+		}
+	}
+	return p
+}
+
+// 谱的长度，即列数
+func (this *Profile) Len() int {
+	return len(this.cols)
+}
+
+// 第j列各字符（大写）的频率，即出现次数占序列数的比例，gap不计入
+func (this *Profile) Frequency(j int) map[byte]float64 {
+	f := make(map[byte]float64, len(this.cols[j]))
+	for _, r := range this.cols[j] {
+		f[r.char] = r.n / float64(len(this.Seqs))
+	}
+	return f
+}
+
+// 第j列中gap所占的比例
+func (this *Profile) Gaps(j int) float64 {
+	n := float64(0)
+	for _, r := range this.cols[j] {
+		n += r.n
+	}
+	return 1 - n/float64(len(this.Seqs))
+}
+
+// 两个谱第i列和第j列的匹配值，即所有非gap字符对的替换分值之和除以两个谱序列数的乘积，gap不计分
+func (this *Profile) score(that *Profile, m *alignment.Matrix, i, j int) float64 {
+	s := float64(0)
+	for _, x := range this.cols[i] {
+		for _, y := range that.cols[j] {
+			s += x.n * y.n * m.Get(x.char, y.char)
+		}
+	}
+	return s / float64(len(this.Seqs)*len(that.Seqs))
+}
+
+// 比对两个谱并合并为一个，所有成员序列中插入一致的gap列
+func (this *Profile) merge(that *Profile, cfg *Config, m *alignment.Matrix) *Profile {
+	mate := func(i, j int) float64 {
+		return this.score(that, m, i, j)
+	}
+	_, _, path := alignment.Align(len(this.cols), len(that.cols), mate, [2]alignment.Gap{cfg.Gap, cfg.Gap}, nil, alignment.Global)
+	seqs := make([]sequence.Sequence, 0, len(this.Seqs)+len(that.Seqs))
+	for _, s := range this.Seqs {
+		s.Char = expand(s.Char, path, 2)
+		seqs = append(seqs, s)
+	}
+	for _, s := range that.Seqs {
+		s.Char = expand(s.Char, path, 1)
+		seqs = append(seqs, s)
+	}
+	index := append(append([]int{}, this.index...), that.index...)
+	return newProfile(index, seqs)
+}
+
+// 将两个谱比对后合并为一个新的谱，两个谱内部已有的比对保持不变，新谱中依次为this和that的序列
+func (this *Profile) Align(that *Profile, cfg *Config) *Profile {
+	if cfg == nil {
+		cfg = &DefaultConfig
+	}
+	p := this.merge(that, cfg, cfg.matrix(append(append([]sequence.Sequence{}, this.Seqs...), that.Seqs...)))
+	for i := range p.index {
+		p.index[i] = i
+	}
+	return p
+}
+
+// 将一个新序列比对到谱上，返回包含该序列（位于最后）的新谱，已有序列之间的比对保持不变，新序列中原有的gap会被删除
+func (this *Profile) Add(s sequence.Sequence, cfg *Config) *Profile {
+	s.Seq = *s.DeleteGaps()
+	return this.Align(newProfile([]int{0}, []sequence.Sequence{s}), cfg)
+}
+
+// 按对齐信息序列在已比对的序列中插入gap，skip为该序列一侧添加空位的编码
+func expand(r []byte, path []byte, skip byte) []byte {
+	t := make([]byte, 0, len(path))
+	i := 0
+	for _, c := range path {
+		if c == skip {
+			t = append(t, '-')
+		} else {
+			t = append(t, r[i])
+			i++
+		}
+	}
+	return t
+}
+
+// 根据序列类型选择替换矩阵
+func (this *Config) matrix(seqs []sequence.Sequence) *alignment.Matrix {
+	switch {
+	case this.Matrix != nil:
+		return this.Matrix
+	case peptide(seqs):
+		return alignment.BLOSUM62
+	default:
+		return alignment.NUC44
+	}
+}
+
+// 判断一组序列是否为多肽，即不都是核酸，见sequence.Nucleic
+func peptide(seqs []sequence.Sequence) bool {
+	s := make([]*sequence.Seq, len(seqs))
+	for i := range seqs {
+		s[i] = &seqs[i].Seq
+	}
+	return !sequence.Nucleic(s...)
+}
+
+// 计算两两序列之间的距离，距离为全局比对中两个序列都不是gap的列里不相同的比例，序列中原有的gap被忽略
+func Distances(seqs []sequence.Sequence, cfg *Config) [][]float64 {
+	if cfg == nil {
+		cfg = &DefaultConfig
+	}
+	s := make([]*sequence.Seq, len(seqs))
+	for i := range seqs {
+		s[i] = seqs[i].DeleteGaps()
+	}
+	b := alignment.Batch{Matrix: cfg.matrix(seqs), Gap: [2]alignment.Gap{cfg.Gap, cfg.Gap}, Mode: alignment.Global, Full: true}
+	res, _ := b.AllPairs(context.Background(), s)
+	d := make([][]float64, len(seqs))
+	for i := range d {
+		d[i] = make([]float64, len(seqs))
+	}
+	for _, r := range res {
+		v := float64(1)
+		if n := r.Alignment.Length() - r.Alignment.Gaps; n != 0 {
+			v = 1 - float64(r.Alignment.Identity)/float64(n)
+		}
+		d[r.I][r.J], d[r.J][r.I] = v, v
+	}
+	return d
+}
+
+// 指导树的节点，叶节点的index为序列下标，内部节点为-1
+type node struct {
+	index int
+	child []*node
+}
+
+// 根据距离矩阵使用UPGMA方法构建指导树，叶节点的名字为序列名
+func GuideTree(seqs []sequence.Sequence, d [][]float64) *cladogram.Tree {
+	names := make([]string, len(seqs))
+	for i := range seqs {
+		names[i] = seqs[i].Name
+	}
+	return cladogram.UPGMA(names, d)
+}
+
+// 按照进化树转换为指导树，叶节点按名字对应到序列，同名的序列按出现顺序依次对应；有叶节点无法对应时返回nil
+func fromTree(t *cladogram.Tree, seqs []sequence.Sequence) *node {
+	index := map[string][]int{}
+	for i := range seqs {
+		index[seqs[i].Name] = append(index[seqs[i].Name], i)
+	}
+	used := 0
+	var conv func(t *cladogram.Tree) *node
+	conv = func(t *cladogram.Tree) *node {
+		if len(t.Leaf) == 0 {
+			l := index[t.Name]
+			if len(l) == 0 {
+				return nil
+			}
+			index[t.Name] = l[1:]
+			used++
+			return &node{index: l[0]}
+		}
+		o := &node{index: -1}
+		for i := range t.Leaf {
+			c := conv(&t.Leaf[i])
+			if c == nil {
+				return nil
+			}
+			o.child = append(o.child, c)
+		}
+		return o
+	}
+	r := conv(t)
+	if used != len(seqs) {
+		return nil
+	}
+	return r
+}
+
+// 按照指导树从叶到根逐步比对，返回与输入顺序相同的带gap序列，可直接用于aln.Write和phy.Write；
+// 指导树的叶节点必须与序列名一一对应，否则返回nil；序列中原有的gap会被删除
+func Progressive(seqs []sequence.Sequence, guide *cladogram.Tree, cfg *Config) []sequence.Sequence {
+	if cfg == nil {
+		cfg = &DefaultConfig
+	}
+	if guide == nil || len(seqs) == 0 {
+		return nil
+	}
+	r := fromTree(guide, seqs)
+	if r == nil {
+		return nil
+	}
+	m := cfg.matrix(seqs)
+	var walk func(n *node) *Profile
+	walk = func(n *node) *Profile {
+		if n.index >= 0 {
+			s := sequence.Sequence{Name: seqs[n.index].Name, Seq: *seqs[n.index].DeleteGaps()}
+			return newProfile([]int{n.index}, []sequence.Sequence{s})
+		}
+		p := walk(n.child[0])
+		for _, c := range n.child[1:] {
+			p = p.merge(walk(c), cfg, m)
+		}
+		return p
+	}
+	p := walk(r)
+	ans := make([]sequence.Sequence, len(seqs))
+	for k, i := range p.index {
+		ans[i] = p.Seqs[k]
+	}
+	return ans
+}
+
+// 渐进式多序列比对，依次计算两两距离、用UPGMA构建指导树并按指导树比对，返回带gap的序列和指导树
+func Align(seqs []sequence.Sequence, cfg *Config) ([]sequence.Sequence, *cladogram.Tree) {
+	if len(seqs) == 0 {
+		return nil, nil
+	}
+	t := GuideTree(seqs, Distances(seqs, cfg))
+	return Progressive(seqs, t, cfg), t
+}
@@ -0,0 +1,110 @@
+package msa
+
+import (
+	"math"
+	"testing"
+
+	"github.com/hydra13142/bio/cladogram"
+	"github.com/hydra13142/bio/sequence"
+)
+
+func named(name, s string) sequence.Sequence {
+	return sequence.Sequence{Name: name, Seq: *sequence.NewForwardSeq([]byte(s))}
+}
+
+func leaves(names ...string) *cladogram.Tree {
+	t := &cladogram.Tree{}
+	for _, n := range names {
+		t.Leaf = append(t.Leaf, cladogram.Tree{Name: n})
+	}
+	return t
+}
+
+func TestProgressive(t *testing.T) {
+	// b缺少a、c第5位的A，输出与输入的顺序相同
+	seqs := []sequence.Sequence{named("b", "ACGTCGT"), named("a", "ACGTACGT"), named("c", "acgt-acgt")}
+	ans, tree := Align(seqs, nil)
+	if tree == nil || len(ans) != 3 {
+		t.Fatalf("Align returned %d sequences and tree %v", len(ans), tree)
+	}
+	want := []string{"b", "ACGT-CGT", "a", "ACGTACGT", "c", "ACGTACGT"}
+	for i, s := range ans {
+		if s.Name != want[2*i] || string(sequence.UpperBytes(s.Char)) != want[2*i+1] {
+			t.Errorf("sequence %d = %s %s, want %s %s", i, s.Name, s.Char, want[2*i], want[2*i+1])
+		}
+	}
+	// 多分叉的指导树与重名的序列
+	seqs = []sequence.Sequence{named("x", "GGATCC"), named("x", "GGTCC"), named("y", "GATCC")}
+	ans = Progressive(seqs, leaves("x", "y", "x"), nil)
+	if len(ans) != 3 {
+		t.Fatalf("Progressive returned %d sequences", len(ans))
+	}
+	for i, s := range ans {
+		if len(s.Char) != len(ans[0].Char) {
+			t.Errorf("sequence %d has length %d, want %d", i, len(s.Char), len(ans[0].Char))
+		}
+		if string(s.DeleteGaps().Char) != string(seqs[i].Char) || s.Name != seqs[i].Name {
+			t.Errorf("sequence %d = %s %s, want %s %s without gaps", i, s.Name, s.Char, seqs[i].Name, seqs[i].Char)
+		}
+	}
+}
+
+func TestFromTree(t *testing.T) {
+	seqs := []sequence.Sequence{named("a", "ACGT"), named("b", "ACGT")}
+	for _, c := range []struct {
+		name string
+		tree *cladogram.Tree
+	}{
+		{"missing leaf", leaves("a", "c")},
+		{"missing sequence", leaves("a")},
+		{"repeated leaf", leaves("a", "b", "b")},
+	} {
+		if fromTree(c.tree, seqs) != nil {
+			t.Errorf("%s: fromTree != nil", c.name)
+		}
+		if Progressive(seqs, c.tree, nil) != nil {
+			t.Errorf("%s: Progressive != nil", c.name)
+		}
+	}
+	if n := fromTree(leaves("b", "a"), seqs); n == nil || n.child[0].index != 1 || n.child[1].index != 0 {
+		t.Errorf("fromTree(b, a) = %v", n)
+	}
+}
+
+func TestProfileAdd(t *testing.T) {
+	seqs := []sequence.Sequence{named("a", "AC-GT"), named("b", "ACAGT")}
+	p := NewProfile(seqs)
+	q := p.Add(named("c", "ACA-GGT"), nil)
+	if q == nil || len(q.Seqs) != 3 {
+		t.Fatalf("Add returned %v", q)
+	}
+	if p.Len() != 5 || string(p.Seqs[0].Char) != "AC-GT" {
+		t.Errorf("Add changed the original profile: %d %s", p.Len(), p.Seqs[0].Char)
+	}
+	// 去掉新插入的列（原有序列都是gap的列）后，原有序列不变
+	for i := range seqs {
+		r := []byte{}
+		for j := 0; j < q.Len(); j++ {
+			if q.Seqs[0].Char[j] != '-' || q.Seqs[1].Char[j] != '-' {
+				r = append(r, q.Seqs[i].Char[j])
+			}
+		}
+		if string(r) != string(seqs[i].Char) || q.Seqs[i].Name != seqs[i].Name {
+			t.Errorf("sequence %d = %s, want %s", i, r, seqs[i].Char)
+		}
+	}
+	if c := q.Seqs[2]; c.Name != "c" || string(c.DeleteGaps().Char) != "ACAGGT" || len(c.Char) != q.Len() {
+		t.Errorf("added sequence = %s %s", c.Name, c.Char)
+	}
+	if q.Len() != 6 || math.Abs(q.Gaps(2)-1.0/3) > 1e-12 || q.Frequency(0)['A'] != 1 {
+		t.Errorf("Len = %d, Gaps(2) = %v, Frequency(0) = %v", q.Len(), q.Gaps(2), q.Frequency(0))
+	}
+	for i, want := range []string{"AC--GT", "ACA-GT", "ACAGGT"} {
+		if string(q.Seqs[i].Char) != want {
+			t.Errorf("row %d = %s, want %s", i, q.Seqs[i].Char, want)
+		}
+	}
+	if NewProfile([]sequence.Sequence{named("a", "ACG"), named("b", "AC")}) != nil {
+		t.Error("NewProfile accepted sequences of different lengths")
+	}
+}
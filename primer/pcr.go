@@ -0,0 +1,146 @@
+package primer
+
+import "github.com/hydra13142/bio/sequence"
+
+// 电子PCR的反应参数
+type Reaction struct {
+	Mismatch int  // 引物3'端区域内允许的最多错配数
+	Tail     int  // 引物3'端用于判断结合的区域长度，区域以外的5'端不计错配；不大于0时使用整个引物
+	Size     int  // 产物的最大长度
+	Circular bool // 模板是否为环状（如质粒）
+}
+
+// 默认的电子PCR反应参数
+var DefaultReaction = Reaction{Mismatch: 1, Tail: 15, Size: 5000}
+
+// 表示一个扩增产物
+type Amplicon struct {
+	// 模板序列的名称
+	Name string
+	// 产物在模板正链上的区间[起点, 终点)，环状模板的产物跨越原点时终点大于模板长度
+	Site [2]int
+	// 产物左、右两端结合的引物，0为正向引物，1为反向引物
+	Primers [2]int
+	// 左、右两端引物在3'端区域内的错配数
+	Mismatch [2]int
+	// 产物的正链序列，两端为引物本身的序列
+	*sequence.Seq
+}
+
+// 用来将碱基和简并碱基（不区分大小写）表示为二进制位的集合构成的整数，其余字符为0
+var baseBinary [256]int
+
+func init() {
+	bit := map[byte]int{'A': 0x1, 'G': 0x2, 'C': 0x4, 'T': 0x8}
+	for c, s := range sequence.Degenerate {
+		for i := 0; i < len(s); i++ {
+			baseBinary[c] |= bit[s[i]]
+		}
+	}
+}
+
+// 引物的一个结合位点
+type binding struct {
+	site     int // 引物覆盖的模板正链区间的起点
+	primer   int // 0为正向引物，1为反向引物
+	mismatch int
+}
+
+// 在模板正链上搜索结合位点，p为要与正链比较的序列，tail为需要比较的区间[起点, 终点)
+func bind(t, p []byte, tail [2]int, m int, circular bool) (o []int, n []int) {
+	l, k := len(t), len(p)
+	end := l - k + 1
+	if circular {
+		end = l
+	}
+	for i := 0; i < end; i++ {
+		x := 0
+		for j := tail[0]; j < tail[1]; j++ {
+			if baseBinary[t[(i+j)%l]]&baseBinary[p[j]] == 0 {
+				if x++; x > m {
+					break
+				}
+			}
+		}
+		if x <= m {
+			o, n = append(o, i), append(n, x)
+		}
+	}
+	return
+}
+
+// 电子PCR，搜索两个引物（5'到3'方向的DNA）在模板序列两条链上的所有结合位点，返回其可能扩增出的所有产物，包括单一引物扩增的产物
+func PCR(fwd, rev *sequence.Seq, templates []sequence.Sequence, r *Reaction) []Amplicon {
+	if fwd.Kind() != "DNA" || rev.Kind() != "DNA" {
+		return nil
+	}
+	if r == nil {
+		r = &DefaultReaction
+	}
+	if fwd.Direction() == "3 => 5" {
+		fwd = fwd.Reverse()
+	}
+	if rev.Direction() == "3 => 5" {
+		rev = rev.Reverse()
+	}
+	primers := [2][]byte{fwd.Char, rev.Char}
+	o := []Amplicon{}
+	for _, tp := range templates {
+		t := tp.Char
+		l := len(t)
+		plus, minus := []binding{}, []binding{}
+		for x, p := range primers {
+			k := len(p)
+			if k == 0 || k > l {
+				continue
+			}
+			n := r.Tail
+			if n <= 0 || n > k {
+				n = k
+			}
+			// 引物与正链相同，结合在负链上，向右延伸
+			s, m := bind(t, p, [2]int{k - n, k}, r.Mismatch, r.Circular)
+			for i := range s {
+				plus = append(plus, binding{s[i], x, m[i]})
+			}
+			// 引物的反向互补序列与正链相同，结合在正链上，向左延伸
+			s, m = bind(t, complement(p), [2]int{0, n}, r.Mismatch, r.Circular)
+			for i := range s {
+				minus = append(minus, binding{s[i], x, m[i]})
+			}
+		}
+		for _, a := range plus {
+			la := len(primers[a.primer])
+			for _, b := range minus {
+				lb := len(primers[b.primer])
+				e := b.site + lb
+				if b.site < a.site {
+					if !r.Circular {
+						continue
+					}
+					e += l
+				}
+				if e-a.site > r.Size || e-a.site < la+lb || (!r.Circular && e > l) {
+					continue
+				}
+				c := make([]byte, 0, e-a.site)
+				c = append(c, primers[a.primer]...)
+				for i := a.site + la; i < e-lb; i++ {
+					c = append(c, t[i%l])
+				}
+				c = append(c, complement(primers[b.primer])...)
+				s := sequence.NewForwardSeq(c)
+				s.AsDNA()
+				o = append(o, Amplicon{tp.Name, [2]int{a.site, e}, [2]int{a.primer, b.primer}, [2]int{a.mismatch, b.mismatch}, s})
+			}
+		}
+	}
+	return o
+}
+
+// 返回DNA序列的反向互补序列，包括简并碱基
+func complement(p []byte) []byte {
+	s := sequence.NewForwardSeq(append([]byte(nil), p...))
+	s.AsDNA()
+	return s.ReverseComplement().Char
+}
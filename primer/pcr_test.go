@@ -0,0 +1,75 @@
+package primer
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hydra13142/bio/sequence"
+)
+
+const (
+	fwd = "GATCCGTAGCTAGGCA"
+	rev = "TGCATCGATCCTAGGA"
+)
+
+func template(s string) []sequence.Sequence {
+	return []sequence.Sequence{{Name: "t", Seq: *sequence.NewForwardSeq([]byte(s))}}
+}
+
+func TestPCRLinear(t *testing.T) {
+	r := string(complement([]byte(rev)))
+	// 小写的模板也能匹配
+	a := PCR(newDNA(fwd), newDNA(rev), template("ttttt"+fwd+"AAAAACCCCCAAAAA"+r+"TTTTT"), &Reaction{Size: 1000})
+	if len(a) != 1 {
+		t.Fatalf("got %d amplicons, want 1", len(a))
+	}
+	if a[0].Name != "t" || a[0].Site != [2]int{5, 52} || a[0].Primers != [2]int{0, 1} || a[0].Mismatch != [2]int{0, 0} {
+		t.Errorf("got %v %v %v %v", a[0].Name, a[0].Site, a[0].Primers, a[0].Mismatch)
+	}
+	if s := string(a[0].Char); s != fwd+"AAAAACCCCCAAAAA"+r {
+		t.Errorf("product %s", s)
+	}
+	if a := PCR(newDNA(fwd), newDNA(rev), template("ttttt"+fwd+"AAAAACCCCCAAAAA"+r+"TTTTT"), &Reaction{Size: 40}); len(a) != 0 {
+		t.Errorf("got %d amplicons longer than Size", len(a))
+	}
+}
+
+func TestPCRCircular(t *testing.T) {
+	r := string(complement([]byte(rev)))
+	tp := template("CCCCC" + r + "TTTTTTTTTT" + fwd + "GGGGG")
+	if a := PCR(newDNA(fwd), newDNA(rev), tp, &Reaction{Size: 1000}); len(a) != 0 {
+		t.Errorf("got %d amplicons on a linear template, want 0", len(a))
+	}
+	a := PCR(newDNA(fwd), newDNA(rev), tp, &Reaction{Size: 1000, Circular: true})
+	if len(a) != 1 {
+		t.Fatalf("got %d amplicons, want 1", len(a))
+	}
+	// 产物跨越原点，终点大于模板长度52
+	if a[0].Site != [2]int{31, 73} {
+		t.Errorf("Site = %v, want [31 73]", a[0].Site)
+	}
+	if s := string(a[0].Char); s != fwd+"GGGGGCCCCC"+r {
+		t.Errorf("product %s", s)
+	}
+}
+
+func TestPCRMismatch(t *testing.T) {
+	r := string(complement([]byte(rev)))
+	tp := template("TTTTT" + fwd + "AAAAACCCCCAAAAA" + r + "TTTTT")
+	// 第6个碱基错配，位于3'端10个碱基之外；第13个碱基错配，位于3'端10个碱基之内
+	outer, inner := []byte(fwd), []byte(fwd)
+	outer[5], inner[12] = 'A', 'C'
+	if a := PCR(newDNA(string(outer)), newDNA(rev), tp, &Reaction{Tail: 10, Size: 1000}); len(a) != 1 || a[0].Mismatch != [2]int{0, 0} {
+		t.Errorf("mismatch outside the tail: %v", a)
+	}
+	if a := PCR(newDNA(string(inner)), newDNA(rev), tp, &Reaction{Tail: 10, Size: 1000}); len(a) != 0 {
+		t.Errorf("got %d amplicons with a mismatch and Mismatch 0", len(a))
+	}
+	a := PCR(newDNA(string(inner)), newDNA(rev), tp, &Reaction{Mismatch: 1, Tail: 10, Size: 1000})
+	if len(a) != 1 {
+		t.Fatalf("got %d amplicons, want 1", len(a))
+	}
+	if a[0].Mismatch != [2]int{1, 0} || !reflect.DeepEqual(a[0].Char[:len(fwd)], inner) {
+		t.Errorf("Mismatch = %v, product %s", a[0].Mismatch, a[0].Char)
+	}
+}
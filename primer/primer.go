@@ -0,0 +1,346 @@
+// PCR引物设计，根据模板序列和目标区域，按照Tm、GC含量、GC夹、长度、产物大小、二级结构和在基因组上的唯一性筛选并评价引物对
+package primer
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/hydra13142/bio/sequence"
+	"github.com/hydra13142/bio/thermodynamics"
+	"github.com/hydra13142/sma"
+)
+
+// 引物设计的参数，各项含义与Primer3的核心参数相对应
+type Config struct {
+	Size    [3]int     // 引物长度的最小值、最适值、最大值
+	Tm      [3]float64 // 引物Tm的最小值、最适值、最大值（摄氏度）
+	GC      [2]float64 // 引物GC含量的最小值、最大值（百分比）
+	Clamp   int        // 引物3'端5个碱基中G、C的最少个数
+	PolyX   int        // 允许的最长单碱基重复
+	Product [3]int     // 产物长度的最小值、最适值、最大值
+	TmDiff  float64    // 引物对之间Tm的最大差值
+	Self    float64    // 自身二聚体的自由能下限（kcal/mol），比它更稳定的引物被淘汰
+	End     float64    // 3'端参与形成的二聚体的自由能下限
+	Hairpin float64    // 发夹结构的自由能下限
+	Cross   float64    // 引物对之间二聚体的自由能下限
+	Seed    int        // 检查在基因组上的唯一性时使用的3'端长度
+	Keep    int        // 组合成引物对之前，每一侧最多保留的候选引物数目
+
+	thermodynamics.Condition // 反应体系
+}
+
+// 默认的引物设计参数
+var DefaultConfig = Config{
+	Size:      [3]int{18, 20, 27},
+	Tm:        [3]float64{57, 60, 63},
+	GC:        [2]float64{20, 80},
+	Clamp:     1,
+	PolyX:     5,
+	Product:   [3]int{100, 200, 1000},
+	TmDiff:    5,
+	Self:      -9,
+	End:       -5,
+	Hairpin:   -3,
+	Cross:     -9,
+	Seed:      12,
+	Keep:      200,
+	Condition: thermodynamics.DefaultCondition}
+
+// 表示一个引物
+type Primer struct {
+	// 引物在模板正链上对应的区间[起点, 终点)
+	Site [2]int
+	*sequence.Seq
+	// 引物的Tm（摄氏度）和GC含量（百分比）
+	Tm, GC float64
+	// 引物偏离最适参数的罚分，越小越好
+	Penalty float64
+}
+
+// 实现fmt.Stringer接口
+func (this Primer) String() string {
+	return fmt.Sprintf("{(%d, %d) %s Tm=%.1f GC=%.1f%%}", this.Site[0], this.Site[1], this.Char, this.Tm, this.GC)
+}
+
+// 表示一对引物
+type Pair struct {
+	Left, Right Primer
+	// 扩增产物的长度
+	Product int
+	// 引物对的总罚分，越小越好
+	Penalty float64
+}
+
+// 设计扩增模板上target区间[起点, 终点)的引物对，genome不为nil时要求引物3'端在基因组上至多只有一个匹配，返回罚分最低的n对引物
+func Design(template *sequence.Seq, target [2]int, genome []sequence.Sequence, cfg *Config, n int) []Pair {
+	if template.Kind() != "DNA" || template.Direction() != "5 => 3" {
+		return nil
+	}
+	if cfg == nil {
+		cfg = &DefaultConfig
+	}
+	l := len(template.Char)
+	if target[0] < 0 || target[1] > l || target[0] > target[1] {
+		return nil
+	}
+	left, right := []Primer{}, []Primer{}
+	// 产物不能超过最大长度，因此左引物的起点不早于target[1]-Product[2]，右引物的终点不晚于target[0]+Product[2]
+	lo, hi := target[1]-cfg.Product[2], target[0]+cfg.Product[2]
+	if lo < 0 {
+		lo = 0
+	}
+	if hi > l {
+		hi = l
+	}
+	for k := cfg.Size[0]; k <= cfg.Size[2]; k++ {
+		for i := lo; i+k <= target[0]; i++ {
+			if p, ok := Evaluate(template.Slice(i, i+k), cfg); ok {
+				p.Site = [2]int{i, i + k}
+				left = append(left, p)
+			}
+		}
+		for i := target[1]; i+k <= hi; i++ {
+			if p, ok := Evaluate(template.Slice(i, i+k).ReverseComplement(), cfg); ok {
+				p.Site = [2]int{i, i + k}
+				right = append(right, p)
+			}
+		}
+	}
+	left, right = best(left, cfg.Keep), best(right, cfg.Keep)
+	pairs := []Pair{}
+	for _, p := range left {
+		for _, q := range right {
+			size := q.Site[1] - p.Site[0]
+			if size < cfg.Product[0] || size > cfg.Product[2] {
+				continue
+			}
+			d := math.Abs(p.Tm - q.Tm)
+			if d > cfg.TmDiff {
+				continue
+			}
+			pairs = append(pairs, Pair{p, q, size, p.Penalty + q.Penalty + d + math.Abs(float64(size-cfg.Product[1]))/100})
+		}
+	}
+	sort.SliceStable(pairs, func(i, j int) bool {
+		return pairs[i].Penalty < pairs[j].Penalty
+	})
+	unique := map[string]bool{}
+	check := func(p *sequence.Seq) bool {
+		if genome == nil {
+			return true
+		}
+		s := string(p.Char)
+		if v, ok := unique[s]; ok {
+			return v
+		}
+		unique[s] = Unique(p, genome, cfg.Seed)
+		return unique[s]
+	}
+	o := []Pair{}
+	for _, p := range pairs {
+		if len(o) >= n {
+			break
+		}
+		if all, _ := Dimer(p.Left.Seq, p.Right.Seq, &cfg.Condition); all < cfg.Cross {
+			continue
+		}
+		if !check(p.Left.Seq) || !check(p.Right.Seq) {
+			continue
+		}
+		o = append(o, p)
+	}
+	return o
+}
+
+// 按罚分从低到高保留前n个候选引物，n不大于0时全部保留
+func best(p []Primer, n int) []Primer {
+	sort.SliceStable(p, func(i, j int) bool {
+		return p[i].Penalty < p[j].Penalty
+	})
+	if n > 0 && len(p) > n {
+		p = p[:n]
+	}
+	return p
+}
+
+// 按照参数评价单个引物（5'到3'方向），不满足任一条件时返回false
+func Evaluate(s *sequence.Seq, cfg *Config) (Primer, bool) {
+	if cfg == nil {
+		cfg = &DefaultConfig
+	}
+	p := Primer{Seq: s}
+	c := s.Char
+	l := len(c)
+	if l < cfg.Size[0] || l > cfg.Size[2] {
+		return p, false
+	}
+	g := 0
+	for _, b := range c {
+		switch b {
+		case 'G', 'C':
+			g++
+		case 'A', 'T':
+		default:
+			return p, false
+		}
+	}
+	p.GC = float64(g) * 100 / float64(l)
+	if p.GC < cfg.GC[0] || p.GC > cfg.GC[1] {
+		return p, false
+	}
+	g = 0
+	for i := l - 1; i >= 0 && i >= l-5; i-- {
+		b := c[i]
+		if b == 'G' || b == 'C' {
+			g++
+		}
+	}
+	if g < cfg.Clamp {
+		return p, false
+	}
+	for i, j := 0, 1; j <= l; j++ {
+		if j == l || c[j] != c[i] {
+			if j-i > cfg.PolyX {
+				return p, false
+			}
+			i = j
+		}
+	}
+	p.Tm = thermodynamics.TmNN(s, &cfg.Condition)
+	if p.Tm < cfg.Tm[0] || p.Tm > cfg.Tm[2] {
+		return p, false
+	}
+	if all, end := Dimer(s, s, &cfg.Condition); all < cfg.Self || end < cfg.End {
+		return p, false
+	}
+	if Hairpin(s, &cfg.Condition) < cfg.Hairpin {
+		return p, false
+	}
+	p.Penalty = math.Abs(p.Tm-cfg.Tm[1]) + math.Abs(float64(l-cfg.Size[1]))
+	return p, true
+}
+
+// 判断两个碱基能否配对
+func pairing(a, b byte) bool {
+	switch a {
+	case 'A':
+		return b == 'T' || b == 'U'
+	case 'T', 'U':
+		return b == 'A'
+	case 'C':
+		return b == 'G'
+	case 'G':
+		return b == 'C'
+	}
+	return false
+}
+
+// 计算一段完全配对的双链在37℃下的自由能
+func stem(c []byte, cond *thermodynamics.Condition) float64 {
+	s := sequence.NewForwardSeq(append([]byte(nil), c...))
+	s.AsDNA()
+	return thermodynamics.DeltaG(s, cond, 37)
+}
+
+// 估计两条序列（均为5'到3'方向）反向平行配对形成二聚体的自由能（kcal/mol），
+// all为任意连续配对区域中最稳定者，end为包含任一序列3'末端碱基的配对区域中最稳定者；
+// 只考虑不含错配和gap的连续配对区域，至少2个碱基对
+func Dimer(p, q *sequence.Seq, cond *thermodynamics.Condition) (all, end float64) {
+	a, b := p.Char, q.Char
+	m, n := len(a), len(b)
+	for k := 0; k < m+n-1; k++ {
+		// 对角线上a[i]与b[k-i]配对
+		i := 0
+		if k >= n {
+			i = k - n + 1
+		}
+		for i <= k && i < m {
+			if !pairing(a[i], b[k-i]) {
+				i++
+				continue
+			}
+			j := i
+			for j <= k && j < m && pairing(a[j], b[k-j]) {
+				j++
+			}
+			if j-i >= 2 {
+				g := stem(a[i:j], cond)
+				if g < all {
+					all = g
+				}
+				if (j == m || k-i == n-1) && g < end {
+					end = g
+				}
+			}
+			i = j
+		}
+	}
+	return
+}
+
+// 发夹环的起始自由能（kcal/mol），SantaLucia 2004参数，超出表格的环长按对数外推
+func loop(n int) float64 {
+	t := []float64{3: 3.5, 4: 3.5, 5: 3.3, 6: 4.0, 7: 4.2, 8: 4.3, 9: 4.5}
+	if n < 9 {
+		return t[n]
+	}
+	return t[9] + 2.44*thermodynamics.R*(37+thermodynamics.Kelvin)*math.Log(float64(n)/9)/1000
+}
+
+// 估计序列（5'到3'方向）形成发夹结构的最低自由能（kcal/mol），茎部为至少2个连续配对的碱基，环至少3个碱基
+func Hairpin(s *sequence.Seq, cond *thermodynamics.Condition) float64 {
+	c := s.Char
+	l := len(c)
+	min := float64(0)
+	for k := 1; k < 2*l-1; k++ {
+		// 反对角线上c[i]与c[k-i]配对，k-2*i-1为环的大小
+		i := 0
+		if k >= l {
+			i = k - l + 1
+		}
+		for k-2*i-1 >= 3 {
+			if !pairing(c[i], c[k-i]) {
+				i++
+				continue
+			}
+			j := i
+			for k-2*j-1 >= 3 && pairing(c[j], c[k-j]) {
+				j++
+			}
+			if j-i >= 2 {
+				if g := stem(c[i:j], cond) + loop(k-2*(j-1)-1); g < min {
+					min = g
+				}
+			}
+			i = j
+		}
+	}
+	return min
+}
+
+// 判断引物3'端长度为n的序列在基因组（正反两条链）上是否至多只有一个完全匹配，不区分大小写；
+// 3'端序列与其反向互补相同（回文）时，两条链上的同一位置只计一次
+func Unique(p *sequence.Seq, genome []sequence.Sequence, n int) bool {
+	c := p.Char
+	if n <= 0 || n > len(c) {
+		n = len(c)
+	}
+	s1 := sequence.UpperBytes(c[len(c)-n:])
+	s2 := sequence.UpperBytes(p.Slice(len(c)-n, 0).ReverseComplement().Char)
+	b1, b2 := sma.NewBM(string(s1)), sma.NewBM(string(s2))
+	ct := 0
+	for _, ch := range genome {
+		g := sequence.UpperBytes(ch.Char)
+		if ct += len(b1.Find(g)); ct > 1 {
+			return false
+		}
+		if string(s1) == string(s2) {
+			continue
+		}
+		if ct += len(b2.Find(g)); ct > 1 {
+			return false
+		}
+	}
+	return true
+}
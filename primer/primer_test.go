@@ -0,0 +1,93 @@
+package primer
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/hydra13142/bio/sequence"
+	"github.com/hydra13142/bio/thermodynamics"
+)
+
+// 长模板上的引物只在产物长度允许的范围内枚举，不会因为保留了远离目标的引物而找不到引物对
+func TestDesignLongTemplate(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	b := make([]byte, 20000)
+	for i := range b {
+		b[i] = "ACGT"[r.Intn(4)]
+	}
+	s := sequence.NewForwardSeq(b)
+	s.AsDNA()
+	target := [2]int{10000, 10100}
+	pairs := Design(s, target, nil, nil, 5)
+	if len(pairs) == 0 {
+		t.Fatal("no primer pairs")
+	}
+	for _, p := range pairs {
+		if p.Left.Site[0] > target[0] || p.Right.Site[1] < target[1] {
+			t.Errorf("pair %v does not flank target", p)
+		}
+		if p.Product > DefaultConfig.Product[2] || p.Product < DefaultConfig.Product[0] {
+			t.Errorf("product %d out of range", p.Product)
+		}
+	}
+}
+
+func newDNA(s string) *sequence.Seq {
+	q := sequence.NewForwardSeq([]byte(s))
+	q.AsDNA()
+	return q
+}
+
+func TestEvaluate(t *testing.T) {
+	cfg := DefaultConfig
+	cfg.Tm = [3]float64{0, 50, 100}
+	cfg.Self, cfg.End, cfg.Hairpin = -100, -100, -100
+	// 3'端5个碱基中没有G、C
+	s := newDNA("GCTAGCTAGCTAGCTATTTA")
+	if _, ok := Evaluate(s, &cfg); ok {
+		t.Error("primer without GC clamp accepted")
+	}
+	cfg.Clamp = 0
+	p, ok := Evaluate(s, &cfg)
+	if !ok {
+		t.Fatal("primer rejected with Clamp 0")
+	}
+	if tm := thermodynamics.TmNN(s, &cfg.Condition); p.Tm != tm || p.GC != 40 {
+		t.Errorf("Tm = %v, GC = %v, want %v, 40", p.Tm, p.GC, tm)
+	}
+	cfg.Tm = [3]float64{p.Tm + 1, p.Tm + 2, p.Tm + 3}
+	if _, ok := Evaluate(s, &cfg); ok {
+		t.Error("primer below the minimum Tm accepted")
+	}
+	cfg.Tm = [3]float64{p.Tm - 3, p.Tm - 2, p.Tm - 1}
+	if _, ok := Evaluate(s, &cfg); ok {
+		t.Error("primer above the maximum Tm accepted")
+	}
+}
+
+func TestUnique(t *testing.T) {
+	genome := func(s ...string) []sequence.Sequence {
+		g := make([]sequence.Sequence, len(s))
+		for i, c := range s {
+			g[i] = sequence.Sequence{Name: "chr", Seq: *sequence.NewForwardSeq([]byte(c))}
+		}
+		return g
+	}
+	for _, c := range []struct {
+		primer string
+		genome []sequence.Sequence
+		want   bool
+	}{
+		// 3'端GAATTC是回文，正反两条链的匹配是同一位置
+		{"CCCCGAATTC", genome("TTTTGAATTCAAAA"), true},
+		{"CCCCGAATTC", genome("TTTTGAATTCAAAA", "CCGAATTCGG"), false},
+		{"CCCCGAATTC", genome("ttttgaattcaaaagaattc"), false},
+		// 正链与反链上各有一个匹配
+		{"CCCCACGGTA", genome("ACGGTATTTTACCGT"), false},
+		{"CCCCACGGTA", genome("acggtatttt"), true},
+	} {
+		if got := Unique(newDNA(c.primer), c.genome, 6); got != c.want {
+			t.Errorf("Unique(%s) on %d sequences = %v, want %v", c.primer, len(c.genome), got, c.want)
+		}
+	}
+}
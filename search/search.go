@@ -0,0 +1,277 @@
+// 类似BLAST的本地序列搜索：对序列集合建立k-mer索引，用双击（two-hit）方法寻找种子，先无gap延伸再有gap延伸，并按E值排序
+package search
+
+import (
+	"math"
+	"sort"
+
+	"github.com/hydra13142/bio/alignment"
+	"github.com/hydra13142/bio/sequence"
+)
+
+// 搜索参数
+type Config struct {
+	Matrix    *alignment.Matrix // 替换矩阵
+	Gap       alignment.Gap     // gap罚分，长度为n的gap罚分为Open+(n-1)*Extend
+	Karlin    alignment.Karlin  // 计算E值的统计参数，为零值时使用替换矩阵的无gap参数（会低估E值）
+	Threshold float64           // 邻近词的最低分值，不大于0时只使用完全相同的词，多肽一般为11
+	Window    int               // 双击的窗口大小，同一对角线上相距不超过该值的两个词才会触发延伸，不大于0时单击即触发
+	DropUngap float64           // 无gap延伸的X-drop值
+	Cutoff    float64           // 无gap延伸的分值不低于该值时才进行有gap延伸
+	DropGap   float64           // 有gap延伸的X-drop值
+	EValue    float64           // 只返回E值不大于该值的结果
+	Both      bool              // 对DNA、RNA同时搜索查询序列的反向互补链
+	Max       int               // 最多返回的结果数，不大于0时不限
+}
+
+// 核酸搜索的默认参数，相当于blastn的reward 2、penalty -3、gap 5/2，建索引时词长一般为11
+var DefaultDNA = Config{
+	Matrix:    alignment.NucleotideMatrix(2, -3),
+	Gap:       alignment.NewGap(7, 2),
+	Karlin:    alignment.Karlin{Lambda: 0.625, K: 0.41, H: 0.78},
+	DropUngap: 20,
+	Cutoff:    20,
+	DropGap:   30,
+	EValue:    10,
+	Both:      true,
+}
+
+// 多肽搜索的默认参数，相当于blastp的BLOSUM62、gap 11/1，建索引时词长一般为3
+var DefaultProtein = Config{
+	Matrix:    alignment.BLOSUM62,
+	Gap:       alignment.NewGap(12, 1),
+	Karlin:    alignment.Karlin{Lambda: 0.267, K: 0.041, H: 0.14},
+	Threshold: 11,
+	Window:    40,
+	DropUngap: 16,
+	Cutoff:    25,
+	DropGap:   38,
+	EValue:    10,
+}
+
+// 词在数据库中出现的位置
+type position struct {
+	seq, pos int32
+}
+
+// 建立了词索引的序列集合
+type Database struct {
+	Seqs  []sequence.Sequence
+	word  int
+	total int
+	index map[string][]position
+}
+
+// 一条搜索结果，比对中序列一为查询序列（Reverse为true时为其反向互补序列），序列二为数据库中下标为Subject的序列
+type Hit struct {
+	Subject int
+	Name    string
+	Reverse bool
+	Bits    float64
+	EValue  float64
+	*alignment.Alignment
+}
+
+// 判断字符能否出现在词中，gap、未知字符和终止符不建索引
+func indexable(c byte) bool {
+	switch c {
+	case '-', '.', '*', 'N', 'X', '?':
+		return false
+	}
+	return c >= 'A' && c <= 'Z'
+}
+
+// 对序列集合建立长度为word的词索引，包含gap、N、X等字符的词不建索引；
+// word不大于0时核酸使用11、多肽使用3（见sequence.Nucleic）
+func NewDatabase(seqs []sequence.Sequence, word int) *Database {
+	if word <= 0 {
+		s := make([]*sequence.Seq, len(seqs))
+		for i := range seqs {
+			s[i] = &seqs[i].Seq
+		}
+		word = 11
+		if !sequence.Nucleic(s...) {
+			word = 3
+		}
+	}
+	db := &Database{Seqs: seqs, word: word, index: map[string][]position{}}
+	for i := range seqs {
+		s := sequence.UpperBytes(seqs[i].Char)
+		db.total += len(s)
+		for j, k := 0, 0; j < len(s); j++ {
+			if !indexable(s[j]) {
+				k = j + 1
+				continue
+			}
+			if j-k+1 >= word {
+				w := string(s[j-word+1 : j+1])
+				db.index[w] = append(db.index[w], position{int32(i), int32(j - word + 1)})
+			}
+		}
+	}
+	return db
+}
+
+// 数据库的总长度
+func (this *Database) Len() int {
+	return this.total
+}
+
+// 替换矩阵中可以组成邻近词的字母，多肽为20种氨基酸，核酸为4种碱基
+func letters(m *alignment.Matrix) []byte {
+	for _, c := range m.Alphabet {
+		if c == 'E' {
+			return []byte("ARNDCQEGHILKMFPSTWYV")
+		}
+	}
+	return []byte("ACGT")
+}
+
+// 返回与查询序列q在i处的词得分不低于threshold的所有词，threshold不大于0时只返回该词本身
+func neighbors(q []byte, i, word int, m *alignment.Matrix, threshold float64, alpha []byte) []string {
+	w := q[i : i+word]
+	for _, c := range w {
+		if !indexable(c) {
+			return nil
+		}
+	}
+	if threshold <= 0 {
+		return []string{string(w)}
+	}
+	// rest[k]为第k个字符之后各位置可能的最高分之和
+	rest := make([]float64, word+1)
+	for k := word - 1; k >= 0; k-- {
+		best := math.Inf(-1)
+		for _, c := range alpha {
+			if v := m.Get(w[k], c); v > best {
+				best = v
+			}
+		}
+		rest[k] = rest[k+1] + best
+	}
+	o := []string{}
+	buf := make([]byte, word)
+	var dfs func(k int, s float64)
+	dfs = func(k int, s float64) {
+		if k == word {
+			o = append(o, string(buf))
+			return
+		}
+		for _, c := range alpha {
+			if v := s + m.Get(w[k], c); v+rest[k+1] >= threshold {
+				buf[k] = c
+				dfs(k+1, v)
+			}
+		}
+	}
+	dfs(0, 0)
+	return o
+}
+
+// 在数据库中搜索查询序列，返回按E值从小到大排列的结果；cfg为nil时多肽查询序列使用DefaultProtein，其余使用DefaultDNA（见sequence.Nucleic）
+func (this *Database) Search(query *sequence.Seq, cfg *Config) []Hit {
+	if cfg == nil {
+		cfg = &DefaultDNA
+		if !sequence.Nucleic(query) {
+			cfg = &DefaultProtein
+		}
+	}
+	if cfg.Karlin.Lambda == 0 {
+		c := *cfg
+		bg := alignment.UniformDNA
+		if len(letters(c.Matrix)) > 4 {
+			bg = alignment.Robinson
+		}
+		c.Karlin = alignment.KarlinAltschul(c.Matrix, bg, bg)
+		cfg = &c
+	}
+	hits := this.strand(sequence.UpperBytes(query.Char), false, cfg)
+	if cfg.Both {
+		if k := query.Kind(); k == "DNA" || k == "RNA" {
+			hits = append(hits, this.strand(sequence.UpperBytes(query.ReverseComplement().Char), true, cfg)...)
+		}
+	}
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].EValue != hits[j].EValue {
+			return hits[i].EValue < hits[j].EValue
+		}
+		return hits[i].Score > hits[j].Score
+	})
+	if cfg.Max > 0 && len(hits) > cfg.Max {
+		hits = hits[:cfg.Max]
+	}
+	return hits
+}
+
+// 对角线上的种子状态，last为上一个词的位置，ext为已经延伸到的位置
+type diagonal struct {
+	last, ext int
+}
+
+// 搜索一条链
+func (this *Database) strand(q []byte, rev bool, cfg *Config) []Hit {
+	w := this.word
+	m := cfg.Matrix
+	alpha := letters(m)
+	gap := [2]alignment.Gap{cfg.Gap, cfg.Gap}
+	diags := map[[2]int]*diagonal{}
+	// 各数据库序列上已经找到的比对的范围，落在其中的种子不再延伸
+	boxes := map[int][][2][2]int{}
+	hits := []Hit{}
+	for i := 0; i+w <= len(q); i++ {
+		for _, word := range neighbors(q, i, w, m, cfg.Threshold, alpha) {
+			for _, p := range this.index[word] {
+				sj, pj := int(p.seq), int(p.pos)
+				key := [2]int{sj, pj - i}
+				d := diags[key]
+				if d == nil {
+					d = &diagonal{-1 << 30, -1}
+					diags[key] = d
+				}
+				if pj < d.ext {
+					continue
+				}
+				if cfg.Window > 0 {
+					switch dist := pj - d.last; {
+					case dist < w:
+						continue
+					case dist > cfg.Window:
+						d.last = pj
+						continue
+					}
+				}
+				d.last = pj
+				s := this.Seqs[sj].Char
+				mate := alignment.MatchBytes(q, s, m.Get)
+				v, o, l := alignment.UngappedXDrop(len(q), len(s), mate, [2]int{i, pj}, cfg.DropUngap)
+				d.ext = o[1] + l
+				if v < cfg.Cutoff {
+					continue
+				}
+				seed := [2]int{o[0] + l/2, o[1] + l/2}
+				if covered(boxes[sj], seed) {
+					continue
+				}
+				v, o, path := alignment.XDrop(len(q), len(s), mate, gap, nil, seed, cfg.DropGap)
+				a := alignment.NewAlignment(v, o, path, q, s, m.Get)
+				boxes[sj] = append(boxes[sj], [2][2]int{a.Start, a.End})
+				e := cfg.Karlin.EValue(v, len(q), this.total)
+				if e > cfg.EValue {
+					continue
+				}
+				hits = append(hits, Hit{sj, this.Seqs[sj].Name, rev, cfg.Karlin.Bits(v), e, a})
+			}
+		}
+	}
+	return hits
+}
+
+// 判断位置是否落在某个已有比对的范围内
+func covered(boxes [][2][2]int, p [2]int) bool {
+	for _, b := range boxes {
+		if p[0] >= b[0][0] && p[0] < b[1][0] && p[1] >= b[0][1] && p[1] < b[1][1] {
+			return true
+		}
+	}
+	return false
+}
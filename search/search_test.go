@@ -0,0 +1,114 @@
+package search
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/hydra13142/bio/sequence"
+)
+
+// 由字母表a中的字符组成的随机序列
+func randomBytes(r *rand.Rand, n int, a string) []byte {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = a[r.Intn(len(a))]
+	}
+	return b
+}
+
+func TestSearchNilConfig(t *testing.T) {
+	r := rand.New(rand.NewSource(5))
+	for _, c := range []struct {
+		alpha string
+		word  int
+		cfg   *Config
+	}{
+		{"ACGT", 11, &DefaultDNA},
+		{"ARNDCQEGHILKMFPSTWYV", 3, &DefaultProtein},
+	} {
+		q := randomBytes(r, 200, c.alpha)
+		g := randomBytes(r, 5000, c.alpha)
+		copy(g[1000:], q)
+		db := NewDatabase([]sequence.Sequence{{Name: "target", Seq: *sequence.NewForwardSeq(g)}}, c.word)
+		s := sequence.NewForwardSeq(q)
+		if c.word == 3 {
+			s.AsPipetide()
+		} else {
+			s.AsDNA()
+		}
+		got, want := db.Search(s, nil), db.Search(s, c.cfg)
+		if len(want) == 0 || want[0].Start[1] != 1000 {
+			t.Fatalf("%s: no hit at 1000: %v", c.alpha, want)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: nil config gives %v, want %v", c.alpha, got, want)
+		}
+	}
+}
+
+func TestNewDatabaseWord(t *testing.T) {
+	r := rand.New(rand.NewSource(6))
+	for _, c := range []struct {
+		alpha string
+		word  int
+	}{
+		{"ACGT", 11},
+		{"ARNDCQEGHILKMFPSTWYV", 3},
+	} {
+		g := randomBytes(r, 1000, c.alpha)
+		db := NewDatabase([]sequence.Sequence{{Name: "target", Seq: *sequence.NewForwardSeq(g)}}, 0)
+		if db == nil || db.word != c.word {
+			t.Fatalf("%s: NewDatabase with word 0 = %v, want word %d", c.alpha, db, c.word)
+		}
+		if h := db.Search(sequence.NewForwardSeq(g[100:300]), nil); len(h) == 0 || h[0].Start[1] != 100 {
+			t.Errorf("%s: no hit at 100: %v", c.alpha, h)
+		}
+	}
+}
+
+func TestSearchStrandAndCutoffs(t *testing.T) {
+	r := rand.New(rand.NewSource(7))
+	q := sequence.NewForwardSeq(randomBytes(r, 200, "ACGT"))
+	q.AsDNA()
+	g := randomBytes(r, 10000, "ACGT")
+	copy(g[1000:], q.ReverseComplement().Char)
+	copy(g[4000:], q.Char)
+	copy(g[7000:], q.Char)
+	db := NewDatabase([]sequence.Sequence{{Name: "target", Seq: *sequence.NewForwardSeq(g)}}, 11)
+	// 默认的E值上限10会包括随机序列中的短比对
+	all := db.Search(q, nil)
+	for _, h := range all {
+		if h.EValue > DefaultDNA.EValue {
+			t.Errorf("hit %+v has E-value above %v", h, DefaultDNA.EValue)
+		}
+	}
+	cfg := DefaultDNA
+	cfg.EValue = 1e-10
+	hits := db.Search(q, &cfg)
+	if len(hits) != 3 || len(all) <= 3 {
+		t.Fatalf("got %d hits with E-value cutoff 1e-10 and %d with 10, want 3 and more", len(hits), len(all))
+	}
+	starts := map[int]bool{}
+	for _, h := range hits {
+		starts[h.Start[1]] = h.Reverse
+		if h.Score != 400 || h.Start[0] != 0 || h.End[0] != 200 {
+			t.Errorf("hit %+v is not the full query", h)
+		}
+	}
+	if rev, ok := starts[1000]; !ok || !rev || starts[4000] || starts[7000] {
+		t.Errorf("hit starts and strands = %v, want 1000 reverse, 4000 and 7000 forward", starts)
+	}
+	cfg.Both = false
+	if h := db.Search(q, &cfg); len(h) != 2 || h[0].Reverse || h[1].Reverse {
+		t.Errorf("got %d hits with Both false, want 2 forward hits", len(h))
+	}
+	cfg.Both, cfg.Max = true, 1
+	if h := db.Search(q, &cfg); len(h) != 1 || !reflect.DeepEqual(h[0], hits[0]) {
+		t.Errorf("Max 1 gives %v, want %v", h, hits[:1])
+	}
+	cfg.Max, cfg.EValue = 0, hits[0].EValue/2
+	if h := db.Search(q, &cfg); len(h) != 0 {
+		t.Errorf("got %d hits with E-value cutoff %v", len(h), cfg.EValue)
+	}
+}
@@ -0,0 +1,50 @@
+package sequence
+
+// 简并碱基（IUPAC）代表的碱基，DNA版本，U视为T，X与N相同；大小写均可查询，RNA使用时将T替换为U
+var Degenerate = map[byte]string{
+	'A': "A", 'C': "C", 'G': "G", 'T': "T", 'U': "T",
+	'R': "AG", 'Y': "CT", 'S': "CG", 'W': "AT", 'K': "GT", 'M': "AC",
+	'B': "CGT", 'D': "AGT", 'H': "ACT", 'V': "ACG", 'N': "ACGT", 'X': "ACGT"}
+
+func init() {
+	for _, c := range []byte("ACGTURYSWKMBDHVNX") {
+		Degenerate[c+'a'-'A'] = Degenerate[c]
+	}
+}
+
+// 小写字母转为大写
+func Upper(c byte) byte {
+	if c >= 'a' && c <= 'z' {
+		return c - 'a' + 'A'
+	}
+	return c
+}
+
+// 返回转为大写后的拷贝
+func UpperBytes(s []byte) []byte {
+	t := make([]byte, len(s))
+	for i, c := range s {
+		t[i] = Upper(c)
+	}
+	return t
+}
+
+// 判断一组序列是否均为核酸：类型为DNA、RNA的视为核酸，多肽不是；类型未知时，含有Degenerate以外的
+// 氨基酸字母（E、F、I、L、P、Q、Z、J、O）或终止符'*'的序列视为多肽，X与N一样可以出现在核酸中
+func Nucleic(seqs ...*Seq) bool {
+	for _, s := range seqs {
+		switch s.Kind() {
+		case "DNA", "RNA":
+			continue
+		case "Peptide":
+			return false
+		}
+		for _, c := range s.Char {
+			switch Upper(c) {
+			case 'E', 'F', 'I', 'L', 'P', 'Q', 'Z', 'J', 'O', '*':
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,37 @@
+package sequence
+
+import "testing"
+
+func TestNucleic(t *testing.T) {
+	for _, c := range []struct {
+		seq  string
+		want bool
+	}{
+		{"ACGTRYN-", true},
+		{"acgun", true},
+		{"acgtx", true},
+		{"MKVGA", true},
+		{"MKVLE", false},
+		{"ACGT*", false},
+	} {
+		if got := Nucleic(NewForwardSeq([]byte(c.seq))); got != c.want {
+			t.Errorf("Nucleic(%q) = %v, want %v", c.seq, got, c.want)
+		}
+	}
+	p := NewForwardSeq([]byte("ACGT"))
+	p.AsPipetide()
+	if Nucleic(p) {
+		t.Error("Nucleic(peptide ACGT) = true, want false")
+	}
+}
+
+func TestDegenerate(t *testing.T) {
+	for _, c := range []byte("ACGTURYSWKMBDHVNX") {
+		if Degenerate[c] == "" || Degenerate[c] != Degenerate[c+'a'-'A'] {
+			t.Errorf("Degenerate[%c] = %q, lower case %q", c, Degenerate[c], Degenerate[c+'a'-'A'])
+		}
+	}
+	if string(UpperBytes([]byte("acgT-u"))) != "ACGT-U" {
+		t.Error("UpperBytes failed")
+	}
+}
@@ -28,7 +28,7 @@ var (
 	// 标准密码子对应的氨基酸，添加了简并碱基和gap的Codon
 	FromCodon = map[string]byte{
 		"AGU": 'S', "GAA": 'E', "GGC": 'G', "GAY": 'D', "GCD": 'A', "CCN": 'P', "UCD": 'S', "GAG": 'E', "AGR": 'R',
-		"GUH": 'V', "CUB": 'L', "ACR": 'T', "GUN": 'V', "GCB": 'A', "UAG": '*', "GGN": 'G', "UGU": 'W', "ACV": 'T',
+		"GUH": 'V', "CUB": 'L', "ACR": 'T', "GUN": 'V', "GCB": 'A', "UAG": '*', "GGN": 'G', "UGU": 'C', "ACV": 'T',
 		"CCD": 'P', "ACB": 'T', "CCH": 'P', "AAC": 'N', "CCG": 'P', "CUU": 'L', "GCA": 'A', "CAG": 'Q', "AUU": 'I',
 		"UAC": 'Y', "CUV": 'L', "UCW": 'S', "UGY": 'C', "GCR": 'A', "CGN": 'R', "GCY": 'A', "GCU": 'A', "CGG": 'R',
 		"UCR": 'S', "UUY": 'F', "AGY": 'S', "CGW": 'R', "GUB": 'V', "UAR": '*', "UCG": 'S', "UGG": 'W', "UCY": 'S',
@@ -39,11 +39,11 @@ var (
 		"AGA": 'R', "ACD": 'T', "AUM": 'I', "GGR": 'G', "CAA": 'Q', "UUU": 'F', "GCG": 'A', "GAC": 'D', "CCU": 'P',
 		"GCV": 'A', "UAU": 'Y', "CGY": 'R', "UUA": 'L', "AAR": 'K', "CGB": 'R', "UAY": 'Y', "UGA": '*', "AUH": 'I',
 		"CUR": 'L', "CAR": 'Q', "CCY": 'P', "UUC": 'F', "CCV": 'P', "GCS": 'A', "UUR": 'L', "CCW": 'P', "AAY": 'N',
-		"GGH": 'G', "CAY": 'H', "CUH": 'L', "CGS": 'R', "GGD": 'G', "GGA": 'G', "GUG": 'V', "CUC": 'L', "ACA": 'T',
+		"AUG": 'M', "GGH": 'G', "CAY": 'H', "CUH": 'L', "CGS": 'R', "GGD": 'G', "GGA": 'G', "GUG": 'V', "CUC": 'L', "ACA": 'T',
 		"GUU": 'V', "CCA": 'P', "ACN": 'T', "GGG": 'G', "GGB": 'G', "CUA": 'L', "CUD": 'L', "GUS": 'V', "GCC": 'A',
 		"GUY": 'V', "UCB": 'S', "CCB": 'P', "UCU": 'S', "CGU": 'R', "CGA": 'R', "CUS": 'L', "GGS": 'G', "UCA": 'S',
 		"AGG": 'R', "GUV": 'V', "GCH": 'A', "GGV": 'G', "CUG": 'L', "AAG": 'K', "UCH": 'S', "GUA": 'V', "ACG": 'T',
-		"UGC": 'W', "ACY": 'T', "UUG": 'L', "---": '-', "ACH": 'T', "ACS": 'T', "GGY": 'G', "ACU": 'T', "GCW": 'A'}
+		"UGC": 'C', "ACY": 'T', "UUG": 'L', "---": '-', "ACH": 'T', "ACS": 'T', "GGY": 'G', "ACU": 'T', "GCW": 'A'}
 
 	// 氨基酸单字母表示对应的三字母表示和中文名
 	AminoAcid = map[byte][2]string{
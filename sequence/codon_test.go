@@ -0,0 +1,24 @@
+package sequence
+
+import "testing"
+
+func TestTranslate(t *testing.T) {
+	for _, c := range []struct {
+		rna, want string
+	}{
+		{"AUGUGUUGCAAAUAA", "MCCK*"},
+		{"AUGUGUUGCAAAUA", "MCCK"},
+		{"AUGUGGUGA", "MW*"},
+	} {
+		s := NewForwardSeq([]byte(c.rna))
+		s.AsRNA()
+		if p := s.Translate(); p == nil || string(p.Char) != c.want {
+			t.Errorf("Translate(%s) = %v, want %s", c.rna, p, c.want)
+		}
+	}
+	s := NewForwardSeq([]byte("ATGTAA"))
+	s.AsDNA()
+	if s.Translate() != nil {
+		t.Error("Translate(DNA) != nil")
+	}
+}
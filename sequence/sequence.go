@@ -222,7 +222,7 @@ func (this *Seq) Slice(i, j int) *Seq {
 func (this *Seq) Reverse() *Seq {
 	s := this.Char
 	t := make([]byte, len(s))
-	for i, j := 0, len(s)-1; i < j; i, j = i+1, j-1 {
+	for i, j := 0, len(s)-1; i <= j; i, j = i+1, j-1 {
 		t[i], t[j] = s[j], s[i]
 	}
 	return &Seq{t, this.kind ^ 1}
@@ -419,7 +419,7 @@ func (this *Seq) Translate() *Seq {
 	l := len(s)
 	t := make([]byte, l/3)
 	if this.kind&1 == 0 {
-		for i, j := 3, 0; i < l; i, j = i+3, j+1 {
+		for i, j := 3, 0; i <= l; i, j = i+3, j+1 {
 			codon := string(s[i-3 : i])
 			if c, ok := FromCodon[codon]; ok {
 				t[j] = c
@@ -428,7 +428,7 @@ func (this *Seq) Translate() *Seq {
 			}
 		}
 	} else {
-		for i, j := 3, 0; i < l; i, j = i+3, j+1 {
+		for i, j := 3, 0; i <= l; i, j = i+3, j+1 {
 			codon := string([]byte{s[i-1], s[i-2], s[i-3]})
 			if c, ok := FromCodon[codon]; ok {
 				t[j] = c
@@ -0,0 +1,22 @@
+package sequence
+
+import "testing"
+
+func TestReverse(t *testing.T) {
+	for _, c := range []struct{ in, out string }{
+		{"A", "A"},
+		{"AC", "CA"},
+		{"ACG", "GCA"},
+		{"GTAAAACGACGGCCAGT", "TGACCGGCAGCAAAATG"},
+	} {
+		s := NewForwardSeq([]byte(c.in))
+		s.AsDNA()
+		r := s.Reverse()
+		if string(r.Char) != c.out {
+			t.Errorf("Reverse(%q) = %q, want %q", c.in, r.Char, c.out)
+		}
+		if r.Direction() != "3 => 5" {
+			t.Errorf("Reverse(%q) direction = %s", c.in, r.Direction())
+		}
+	}
+}
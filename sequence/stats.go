@@ -0,0 +1,208 @@
+package sequence
+
+import "math"
+
+// 碱基组成的计数，简并碱基中只有S、W能确定是否为G/C，其余简并碱基、gap和非法字符不参与计数
+type Composition struct {
+	A, C, G, T int // RNA的U计入T
+	S, W       int // 不确定具体碱基的G/C和A/T
+	Other      int // 其余简并碱基和非法字符，不包括gap
+}
+
+// 统计碱基组成，不适用于多肽
+func (this *Seq) Composition() Composition {
+	var c Composition
+	for _, b := range this.Char {
+		switch b {
+		case 'A':
+			c.A++
+		case 'C':
+			c.C++
+		case 'G':
+			c.G++
+		case 'T', 'U':
+			c.T++
+		case 'S':
+			c.S++
+		case 'W':
+			c.W++
+		case '-':
+		default:
+			c.Other++
+		}
+	}
+	return c
+}
+
+// 返回GC含量（0到1之间），只适用于DNA、RNA，否则或没有可计数的碱基时返回NaN
+func (this *Seq) GC() float64 {
+	if k := this.kind >> 3; k != 1 && k != 2 {
+		return math.NaN()
+	}
+	c := this.Composition()
+	gc := c.G + c.C + c.S
+	if n := gc + c.A + c.T + c.W; n != 0 {
+		return float64(gc) / float64(n)
+	}
+	return math.NaN()
+}
+
+// 返回GC偏斜(G-C)/(G+C)，只适用于DNA、RNA，否则或没有G、C时返回NaN
+func (this *Seq) GCSkew() float64 {
+	if k := this.kind >> 3; k != 1 && k != 2 {
+		return math.NaN()
+	}
+	c := this.Composition()
+	if c.G+c.C == 0 {
+		return math.NaN()
+	}
+	return float64(c.G-c.C) / float64(c.G+c.C)
+}
+
+// 返回CpG二核苷酸的观测值/期望值比例（CpG*N/(C*G)），只适用于DNA、RNA，否则或没有C、G时返回NaN
+func (this *Seq) CpG() float64 {
+	if k := this.kind >> 3; k != 1 && k != 2 {
+		return math.NaN()
+	}
+	c := this.Composition()
+	if c.C == 0 || c.G == 0 {
+		return math.NaN()
+	}
+	n := 0
+	for _, v := range this.Kmers(1) {
+		n += v
+	}
+	return float64(this.Kmers(2)["CG"]) * float64(n) / float64(c.C*c.G)
+}
+
+// 判断字符是否为确定的碱基（或氨基酸），用于k-mer统计
+func (this *Seq) definite(b byte) bool {
+	switch this.kind >> 3 {
+	case 1:
+		return b == 'A' || b == 'C' || b == 'G' || b == 'T'
+	case 2:
+		return b == 'A' || b == 'C' || b == 'G' || b == 'U'
+	case 3:
+		return b >= 'A' && b <= 'Z' && b != 'X'
+	default:
+		return b != '-' && b != '?'
+	}
+}
+
+// 统计长度为k的子序列的出现次数，包含简并碱基、gap或非法字符的子序列不计入
+func (this *Seq) Kmers(k int) map[string]int {
+	s := this.Char
+	m := map[string]int{}
+	if k <= 0 {
+		return m
+	}
+	for i, j := 0, 0; j < len(s); j++ {
+		if !this.definite(s[j]) {
+			i = j + 1
+			continue
+		}
+		if j-i+1 >= k {
+			m[string(s[j-k+1:j+1])]++
+		}
+	}
+	return m
+}
+
+// 返回长度为k的子序列的频率，即其出现次数占全部计数的子序列的比例
+func (this *Seq) Frequency(k int) map[string]float64 {
+	m := this.Kmers(k)
+	n := 0
+	for _, v := range m {
+		n += v
+	}
+	f := make(map[string]float64, len(m))
+	for s, v := range m {
+		f[s] = float64(v) / float64(n)
+	}
+	return f
+}
+
+// 返回二核苷酸的相对丰度f(xy)/(f(x)f(y))，用于分析二核苷酸的偏好性
+func (this *Seq) Dinucleotide() map[string]float64 {
+	one, two := this.Frequency(1), this.Frequency(2)
+	r := make(map[string]float64, len(two))
+	for s, v := range two {
+		r[s] = v / (one[s[:1]] * one[s[1:]])
+	}
+	return r
+}
+
+// 返回字符组成的香农熵（比特），gap、简并碱基和非法字符不参与计算
+func (this *Seq) Entropy() float64 {
+	e := float64(0)
+	for _, p := range this.Frequency(1) {
+		e -= p * math.Log2(p)
+	}
+	return e
+}
+
+// 返回序列的DUST分值，即所有三核苷酸出现次数c的c(c-1)/2之和除以(三核苷酸总数-1)，分值越高复杂度越低
+func (this *Seq) Dust() float64 {
+	n, s := 0, 0
+	for _, c := range this.Kmers(3) {
+		n += c
+		s += c * (c - 1) / 2
+	}
+	if n <= 1 {
+		return 0
+	}
+	return float64(s) / float64(n-1)
+}
+
+// 以size为窗口长度、step为步长，对每个窗口计算统计量f，返回的slice依次对应各窗口
+func (this *Seq) Sliding(size, step int, f func(*Seq) float64) []float64 {
+	l := len(this.Char)
+	if size <= 0 || step <= 0 || size > l {
+		return nil
+	}
+	o := make([]float64, 0, (l-size)/step+1)
+	for i := 0; i+size <= l; i += step {
+		o = append(o, f(&Seq{this.Char[i : i+size], this.kind}))
+	}
+	return o
+}
+
+// 搜索低复杂度区域，对所有长度为size的窗口计算统计量f，f大于（high为true）或小于（high为false）阈值的窗口合并为区间[起点, 终点)返回
+//
+// 例如DUST方法使用Seq.Dust、窗口64、阈值20，high为true；熵方法使用Seq.Entropy、窗口12、阈值1.5，high为false
+func (this *Seq) LowComplexity(size int, f func(*Seq) float64, threshold float64, high bool) [][2]int {
+	v := this.Sliding(size, 1, f)
+	o := [][2]int{}
+	for i, x := range v {
+		if (high && x > threshold) || (!high && x < threshold) {
+			if n := len(o); n != 0 && o[n-1][1] >= i {
+				o[n-1][1] = i + size
+			} else {
+				o = append(o, [2]int{i, i + size})
+			}
+		}
+	}
+	return o
+}
+
+// 返回将指定区间屏蔽后的序列，DNA、RNA用N屏蔽，多肽用X屏蔽，gap保持不变
+func (this *Seq) Mask(regions [][2]int) *Seq {
+	t := make([]byte, len(this.Char))
+	copy(t, this.Char)
+	c := byte('N')
+	if this.kind>>3 == 3 {
+		c = 'X'
+	}
+	for _, r := range regions {
+		for i := r[0]; i < r[1] && i < len(t); i++ {
+			if i >= 0 && t[i] != '-' {
+				t[i] = c
+			}
+		}
+	}
+	k := this.kind
+	if c == 'N' && len(regions) != 0 {
+		k |= 2
+	}
+	return &Seq{t, k}
+}
@@ -0,0 +1,68 @@
+package sequence
+
+import (
+	"math"
+	"testing"
+)
+
+func dna(s string) *Seq {
+	q := NewForwardSeq([]byte(s))
+	q.AsDNA()
+	return q
+}
+
+func TestStats(t *testing.T) {
+	p := NewForwardSeq([]byte("MKV"))
+	p.AsPipetide()
+	nan := math.NaN()
+	for _, c := range []struct {
+		name string
+		got  float64
+		want float64
+	}{
+		{"GC(ACGT)", dna("ACGT").GC(), 0.5},
+		{"GC(GGGCA)", dna("GGGCA").GC(), 0.8},
+		// S计入G/C，W计入A/T，gap不计
+		{"GC(ACGS-W)", dna("ACGS-W").GC(), 0.6},
+		// N不计
+		{"GC(ACGN)", dna("ACGN").GC(), 2.0 / 3},
+		{"GC(NNNN)", dna("NNNN").GC(), nan},
+		{"GC(peptide)", p.GC(), nan},
+		{"GCSkew(GGGC)", dna("GGGC").GCSkew(), 0.5},
+		{"GCSkew(ATAT)", dna("ATAT").GCSkew(), nan},
+		// 2个CG，6个碱基，C、G各2个：2×6/(2×2)
+		{"CpG(ACGCGT)", dna("ACGCGT").CpG(), 3},
+		{"Entropy(ACGT)", dna("ACGT").Entropy(), 2},
+		{"Entropy(AC-GT)", dna("AC-GT").Entropy(), 2},
+		{"Entropy(AAAA)", dna("AAAA").Entropy(), 0},
+		{"Entropy(AACC)", dna("AACC").Entropy(), 1},
+		// -0.75log2(0.75)-0.25log2(0.25)
+		{"Entropy(AAAC)", dna("AAAC").Entropy(), 0.8112781244591328},
+		// 4个AAA：4×3/2/(4-1)
+		{"Dust(AAAAAA)", dna("AAAAAA").Dust(), 2},
+		// 8个AAA：8×7/2/(8-1)
+		{"Dust(A×10)", dna("AAAAAAAAAA").Dust(), 4},
+		// ACG、CGT各2个，GTA、TAC各1个：(1+1)/(6-1)
+		{"Dust(ACGTACGT)", dna("ACGTACGT").Dust(), 0.4},
+		{"Dust(ACG)", dna("ACG").Dust(), 0},
+	} {
+		if math.IsNaN(c.want) {
+			if !math.IsNaN(c.got) {
+				t.Errorf("%s = %v, want NaN", c.name, c.got)
+			}
+		} else if math.Abs(c.got-c.want) > 1e-12 {
+			t.Errorf("%s = %v, want %v", c.name, c.got, c.want)
+		}
+	}
+}
+
+func TestLowComplexity(t *testing.T) {
+	s := dna("ACGTTGCAAAAAAAAAAAAGCTTGCA")
+	r := s.LowComplexity(6, (*Seq).Entropy, 0.5, false)
+	if len(r) != 1 || r[0] != [2]int{7, 19} {
+		t.Fatalf("LowComplexity = %v, want [[7 19]]", r)
+	}
+	if m := string(s.Mask(r).Char); m != "ACGTTGCNNNNNNNNNNNNGCTTGCA" {
+		t.Errorf("Mask = %s", m)
+	}
+}
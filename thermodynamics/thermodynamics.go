@@ -0,0 +1,290 @@
+// 核酸的热力学计算，包括解链温度（Tm）的几种估算方法，以及基于最近邻模型的DNA/DNA、RNA/RNA双链的焓变、熵变和自由能
+package thermodynamics
+
+import (
+	"math"
+
+	"github.com/hydra13142/bio/sequence"
+)
+
+// 理想气体常数，单位cal/(K·mol)
+const R = 1.9872
+
+// 摄氏度与热力学温度的差值
+const Kelvin = 273.15
+
+// 反应体系中各成分的浓度，离子和dNTP的单位为mM，寡核苷酸的单位为nM
+type Condition struct {
+	Na    float64 // 钠离子浓度
+	K     float64 // 钾离子浓度
+	Tris  float64 // Tris缓冲液浓度
+	Mg    float64 // 镁离子浓度
+	DNTP  float64 // dNTP浓度，dNTP会螯合镁离子
+	Oligo float64 // 寡核苷酸（引物、探针）的浓度
+}
+
+// 默认的反应体系，与常用PCR体系及Primer3的默认值相同
+var DefaultCondition = Condition{Na: 50, Mg: 1.5, DNTP: 0.6, Oligo: 50}
+
+// 将各种阳离子折算为等效的一价阳离子浓度（mM），二价镁离子按von Ahsen等人的经验公式折算，并扣除被dNTP螯合的部分
+func (this *Condition) Monovalent() float64 {
+	m := this.Na + this.K + this.Tris/2
+	if f := this.Mg - this.DNTP; f > 0 {
+		m += 120 * math.Sqrt(f)
+	}
+	return m
+}
+
+// 双链形成过程的热力学参数，焓变单位kcal/mol，熵变单位cal/(K·mol)
+type Energy struct {
+	Enthalpy float64
+	Entropy  float64
+}
+
+// 返回指定温度（摄氏度）下的吉布斯自由能变化，单位kcal/mol
+func (this Energy) Gibbs(t float64) float64 {
+	return this.Enthalpy - (t+Kelvin)*this.Entropy/1000
+}
+
+// 最近邻模型的参数表
+type table struct {
+	pair map[string]Energy // 二核苷酸对（按5'到3'方向的上链书写）的参数
+	init Energy            // 起始参数，每个双链计入一次
+	gc   Energy            // 每个末端为G·C碱基对时的参数
+	at   Energy            // 每个末端为A·T（A·U）碱基对时的参数
+	sym  Energy            // 自身互补序列的对称性修正
+}
+
+// DNA/DNA双链的最近邻参数，SantaLucia 1998统一参数
+var dna = table{
+	pair: map[string]Energy{
+		"AA": {-7.9, -22.2}, "TT": {-7.9, -22.2},
+		"AT": {-7.2, -20.4},
+		"TA": {-7.2, -21.3},
+		"CA": {-8.5, -22.7}, "TG": {-8.5, -22.7},
+		"GT": {-8.4, -22.4}, "AC": {-8.4, -22.4},
+		"CT": {-7.8, -21.0}, "AG": {-7.8, -21.0},
+		"GA": {-8.2, -22.2}, "TC": {-8.2, -22.2},
+		"CG": {-10.6, -27.2},
+		"GC": {-9.8, -24.4},
+		"GG": {-8.0, -19.9}, "CC": {-8.0, -19.9}},
+	gc:  Energy{0.1, -2.8},
+	at:  Energy{2.3, 4.1},
+	sym: Energy{0, -1.4}}
+
+// RNA/RNA双链的最近邻参数，Xia 1998参数
+var rna = table{
+	pair: map[string]Energy{
+		"AA": {-6.82, -19.0}, "UU": {-6.82, -19.0},
+		"AU": {-9.38, -26.7},
+		"UA": {-7.69, -20.5},
+		"CA": {-10.44, -26.9}, "UG": {-10.44, -26.9},
+		"GU": {-11.40, -29.5}, "AC": {-11.40, -29.5},
+		"CU": {-10.48, -27.1}, "AG": {-10.48, -27.1},
+		"GA": {-12.44, -32.5}, "UC": {-12.44, -32.5},
+		"CG": {-10.64, -26.7},
+		"GC": {-14.88, -36.9},
+		"GG": {-13.39, -32.7}, "CC": {-13.39, -32.7}},
+	init: Energy{3.61, -1.5},
+	at:   Energy{3.72, 10.5},
+	sym:  Energy{0, -1.4}}
+
+// 将序列整理为5'到3'方向、去除gap的字节切片，并返回其使用的参数表；非DNA、RNA序列返回nil
+func prepare(s *sequence.Seq) ([]byte, *table) {
+	var t *table
+	switch s.Kind() {
+	case "DNA":
+		t = &dna
+	case "RNA":
+		t = &rna
+	default:
+		return nil, nil
+	}
+	if s.Direction() == "3 => 5" {
+		s = s.Reverse()
+	}
+	return s.DeleteGaps().Char, t
+}
+
+// 返回碱基c可能代表的碱基集合，U和T视为相同
+func expand(c byte, t *table) string {
+	e, ok := sequence.Degenerate[c]
+	if !ok {
+		e = "ACGT"
+	}
+	if t == &rna {
+		b := []byte(e)
+		for i, x := range b {
+			if x == 'T' {
+				b[i] = 'U'
+			}
+		}
+		e = string(b)
+	}
+	return e
+}
+
+// 二核苷酸对的参数，简并碱基取其所有可能组合的平均值
+func (this *table) step(a, b byte) Energy {
+	p, q := expand(a, this), expand(b, this)
+	var e Energy
+	for i := 0; i < len(p); i++ {
+		for j := 0; j < len(q); j++ {
+			f := this.pair[string([]byte{p[i], q[j]})]
+			e.Enthalpy += f.Enthalpy
+			e.Entropy += f.Entropy
+		}
+	}
+	n := float64(len(p) * len(q))
+	return Energy{e.Enthalpy / n, e.Entropy / n}
+}
+
+// 末端碱基对的参数，简并碱基按G·C所占比例加权
+func (this *table) end(c byte) Energy {
+	p := expand(c, this)
+	var e Energy
+	for i := 0; i < len(p); i++ {
+		f := this.at
+		if p[i] == 'G' || p[i] == 'C' {
+			f = this.gc
+		}
+		e.Enthalpy += f.Enthalpy
+		e.Entropy += f.Entropy
+	}
+	n := float64(len(p))
+	return Energy{e.Enthalpy / n, e.Entropy / n}
+}
+
+// 判断序列是否是自身互补的（回文序列）
+func symmetric(s []byte) bool {
+	for i, j := 0, len(s)-1; i <= j; i, j = i+1, j-1 {
+		switch s[i] {
+		case 'A':
+			if s[j] != 'T' && s[j] != 'U' {
+				return false
+			}
+		case 'T', 'U':
+			if s[j] != 'A' {
+				return false
+			}
+		case 'C':
+			if s[j] != 'G' {
+				return false
+			}
+		case 'G':
+			if s[j] != 'C' {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// 计算序列与其完全互补链形成双链的焓变和熵变（未做盐浓度修正），只适用于DNA、RNA；否则返回false
+func Duplex(s *sequence.Seq) (Energy, bool) {
+	c, t := prepare(s)
+	if t == nil || len(c) < 2 {
+		return Energy{}, false
+	}
+	e := t.init
+	add := func(f Energy) {
+		e.Enthalpy += f.Enthalpy
+		e.Entropy += f.Entropy
+	}
+	for i := 1; i < len(c); i++ {
+		add(t.step(c[i-1], c[i]))
+	}
+	add(t.end(c[0]))
+	add(t.end(c[len(c)-1]))
+	if symmetric(c) {
+		add(t.sym)
+	}
+	return e, true
+}
+
+// 对熵变进行盐浓度修正（SantaLucia 1998），m为等效一价阳离子浓度（mM），n为序列长度
+func salt(e Energy, m float64, n int) Energy {
+	if m <= 0 {
+		return e
+	}
+	e.Entropy += 0.368 * float64(n-1) * math.Log(m/1000)
+	return e
+}
+
+// 计算指定反应体系下双链形成的吉布斯自由能变化（kcal/mol），t为温度（摄氏度）；非DNA、RNA序列返回NaN
+func DeltaG(s *sequence.Seq, c *Condition, t float64) float64 {
+	e, ok := Duplex(s)
+	if !ok {
+		return math.NaN()
+	}
+	if c == nil {
+		c = &DefaultCondition
+	}
+	n, _ := prepare(s)
+	return salt(e, c.Monovalent(), len(n)).Gibbs(t)
+}
+
+// 最简单的Tm估算（Wallace规则及其长序列修正），短于14个碱基时Tm=2(A+T)+4(G+C)；非DNA、RNA序列返回NaN
+func TmBasic(s *sequence.Seq) float64 {
+	c, t := prepare(s)
+	if t == nil || len(c) == 0 {
+		return math.NaN()
+	}
+	n := float64(len(c))
+	g := gcCount(c)
+	if len(c) < 14 {
+		return 2*(n-g) + 4*g
+	}
+	return 64.9 + 41*(g-16.4)/n
+}
+
+// 考虑盐浓度的Tm估算（Howley公式），使用等效一价阳离子浓度；非DNA、RNA序列返回NaN
+func TmSalt(s *sequence.Seq, c *Condition) float64 {
+	p, t := prepare(s)
+	if t == nil || len(p) == 0 {
+		return math.NaN()
+	}
+	if c == nil {
+		c = &DefaultCondition
+	}
+	n := float64(len(p))
+	g := gcCount(p)
+	return 100.5 + 41*g/n - 820/n + 16.6*math.Log10(c.Monovalent()/1000)
+}
+
+// 基于SantaLucia最近邻模型的Tm计算，包含盐、镁离子、dNTP和寡核苷酸浓度的修正；非DNA、RNA序列返回NaN
+func TmNN(s *sequence.Seq, c *Condition) float64 {
+	e, ok := Duplex(s)
+	if !ok {
+		return math.NaN()
+	}
+	if c == nil {
+		c = &DefaultCondition
+	}
+	p, _ := prepare(s)
+	e = salt(e, c.Monovalent(), len(p))
+	ct := c.Oligo * 1e-9
+	if !symmetric(p) {
+		ct /= 4
+	}
+	return e.Enthalpy*1000/(e.Entropy+R*math.Log(ct)) - Kelvin
+}
+
+// 统计G、C碱基的数目，简并碱基按其代表G、C的比例计数
+func gcCount(s []byte) float64 {
+	g := float64(0)
+	for _, c := range s {
+		e, ok := sequence.Degenerate[c]
+		if !ok {
+			e = "ACGT"
+		}
+		for i := 0; i < len(e); i++ {
+			if e[i] == 'G' || e[i] == 'C' {
+				g += 1 / float64(len(e))
+			}
+		}
+	}
+	return g
+}
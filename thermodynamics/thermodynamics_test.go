@@ -0,0 +1,72 @@
+package thermodynamics
+
+import (
+	"math"
+	"testing"
+
+	"github.com/hydra13142/bio/sequence"
+)
+
+// 3'到5'方向的奇数长度序列与其5'到3'方向的形式应得到相同的Tm
+func TestTmNNDirection(t *testing.T) {
+	f := sequence.NewForwardSeq([]byte("GTAAAACGACGGCCAGT"))
+	f.AsDNA()
+	r := sequence.NewReverseSeq([]byte("TGACCGGCAGCAAAATG"))
+	r.AsDNA()
+	a, b := TmNN(f, &DefaultCondition), TmNN(r, &DefaultCondition)
+	if math.IsNaN(a) || math.Abs(a-b) > 1e-9 {
+		t.Fatalf("TmNN forward %v, reverse %v", a, b)
+	}
+}
+
+func newDNA(s string) *sequence.Seq {
+	q := sequence.NewForwardSeq([]byte(s))
+	q.AsDNA()
+	return q
+}
+
+// SantaLucia 1998的算例：5'-CGTTGA-3'在1M NaCl、37℃下ΔG=-5.35 kcal/mol（按ΔH、ΔS计算为-5.41）
+func TestDeltaG(t *testing.T) {
+	c := &Condition{Na: 1000, Oligo: 1e5}
+	if g := DeltaG(newDNA("CGTTGA"), c, 37); math.Abs(g+5.35) > 0.1 {
+		t.Errorf("DeltaG(CGTTGA) = %v, want -5.35", g)
+	}
+	if g := DeltaG(newDNA("GGAATTCC"), c, 37); math.Abs(g+6.8166) > 1e-6 {
+		t.Errorf("DeltaG(GGAATTCC) = %v, want -6.8166", g)
+	}
+	if g := DeltaG(newDNA("CGTTGA"), nil, 37); math.Abs(g+4.3764) > 1e-4 {
+		t.Errorf("DeltaG(CGTTGA, default) = %v, want -4.3764", g)
+	}
+	p := sequence.NewForwardSeq([]byte("MKV"))
+	p.AsPipetide()
+	if g := DeltaG(p, nil, 37); !math.IsNaN(g) {
+		t.Errorf("DeltaG(peptide) = %v, want NaN", g)
+	}
+}
+
+func TestTm(t *testing.T) {
+	c := &Condition{Na: 1000, Oligo: 1e5}
+	for _, x := range []struct {
+		name string
+		got  float64
+		want float64
+	}{
+		// ΔH=-41.2、ΔS=-115.4，非自身互补，CT/4
+		{"TmNN(CGTTGA)", TmNN(newDNA("CGTTGA"), c), 28.7752},
+		// ΔH=-55.2、ΔS=-156.0（含对称性修正），自身互补，CT
+		{"TmNN(GGAATTCC)", TmNN(newDNA("GGAATTCC"), c), 43.5403},
+		// 等效一价阳离子50+120√0.9 mM
+		{"TmNN(CGTTGA, default)", TmNN(newDNA("CGTTGA"), nil), -7.1555},
+		{"TmBasic(ACGTACGT)", TmBasic(newDNA("ACGTACGT")), 24},
+		{"TmBasic(ACGTACGN)", TmBasic(newDNA("ACGTACGN")), 25},
+		{"TmBasic(20mer)", TmBasic(newDNA("ACGTACGTACGTACGTACGT")), 51.78},
+		{"TmSalt(20mer)", TmSalt(newDNA("ACGTACGTACGTACGTACGT"), &Condition{Na: 50}), 58.4029},
+	} {
+		if math.Abs(x.got-x.want) > 1e-4 {
+			t.Errorf("%s = %v, want %v", x.name, x.got, x.want)
+		}
+	}
+	if v := TmBasic(newDNA("--")); !math.IsNaN(v) {
+		t.Errorf("TmBasic(gaps) = %v, want NaN", v)
+	}
+}